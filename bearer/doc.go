@@ -0,0 +1,3 @@
+// Package bearer implements the usage of JWTs as OAuth 2.0 bearer tokens in
+// HTTP requests as specified in RFC 6750 (https://datatracker.ietf.org/doc/html/rfc6750).
+package bearer
@@ -0,0 +1,280 @@
+package bearer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/halimath/jose/jwt"
+)
+
+const (
+	// ParamAccessToken is the name of the form and query parameter carrying
+	// the access token as defined in RFC 6750 sections 2.2 and 2.3.
+	ParamAccessToken = "access_token"
+
+	// Error codes defined in RFC 6750 section 3.1
+	ErrorCodeInvalidRequest = "invalid_request"
+	ErrorCodeInvalidToken   = "invalid_token"
+)
+
+var (
+	// ErrNoToken is returned (maybe wrapped) from ExtractToken if a request
+	// carries no bearer token.
+	ErrNoToken = errors.New("no bearer token")
+
+	// ErrInvalidRequest is returned (maybe wrapped) from ExtractToken if a
+	// request carries a malformed token or more than one token.
+	ErrInvalidRequest = errors.New("invalid bearer token request")
+)
+
+// Options defines the options for Middleware.
+type Options struct {
+	// KeyResolver resolves the keys to verify a token's signature. If
+	// non-nil, jwt.SignatureFromResolver(KeyResolver) is applied before
+	// Verifiers.
+	KeyResolver jwt.KeyResolver
+
+	// Verifiers are applied to each decoded token. If KeyResolver is nil,
+	// Verifiers must contain a verifier checking the token's signature.
+	Verifiers []jwt.Verifier
+
+	// DecodeOptions are used to decode tokens.
+	DecodeOptions jwt.DecodeOptions
+
+	// AllowFormParameter enables extracting tokens from the
+	// "access_token" parameter of an urlencoded form body as defined in RFC
+	// 6750 section 2.2.
+	AllowFormParameter bool
+
+	// AllowQueryParameter enables extracting tokens from the "access_token"
+	// query parameter as defined in RFC 6750 section 2.3. Using this method
+	// is discouraged as tokens may leak via logs.
+	AllowQueryParameter bool
+
+	// Realm is sent as the "realm" parameter of the WWW-Authenticate header.
+	Realm string
+}
+
+// ExtractOptions defines the methods ExtractToken uses in addition to the
+// Authorization header. The zero value only uses the Authorization header.
+type ExtractOptions struct {
+	// AllowFormParameter enables extracting tokens from the
+	// "access_token" parameter of an urlencoded form body as defined in RFC
+	// 6750 section 2.2.
+	AllowFormParameter bool
+
+	// AllowQueryParameter enables extracting tokens from the "access_token"
+	// query parameter as defined in RFC 6750 section 2.3. Using this method
+	// is discouraged as tokens may leak via logs.
+	AllowQueryParameter bool
+}
+
+// Middleware returns a middleware that authenticates requests using bearer
+// tokens. Requests carrying a valid token are passed to the next handler
+// with the token stored in the request's context; use TokenFromContext to
+// obtain it. Other requests are rejected with a response as defined in
+// RFC 6750 section 3.
+//
+// Middleware panics if opts contains neither a KeyResolver nor Verifiers as
+// tokens would not be verified at all. It also panics if Verifiers contains
+// nil or Realm contains control characters which cannot be sent in a
+// WWW-Authenticate header.
+func Middleware(opts Options) func(http.Handler) http.Handler {
+	if opts.KeyResolver == nil && len(opts.Verifiers) == 0 {
+		panic("bearer: neither KeyResolver nor Verifiers configured")
+	}
+
+	for _, v := range opts.Verifiers {
+		if v == nil {
+			panic("bearer: nil Verifier configured")
+		}
+	}
+
+	if !isQuotable(opts.Realm) {
+		panic("bearer: Realm contains control characters")
+	}
+
+	verifiers := make([]jwt.Verifier, 0, len(opts.Verifiers)+1)
+	if opts.KeyResolver != nil {
+		verifiers = append(verifiers, jwt.SignatureFromResolver(opts.KeyResolver))
+	}
+	verifiers = append(verifiers, opts.Verifiers...)
+
+	extractOpts := ExtractOptions{
+		AllowFormParameter:  opts.AllowFormParameter,
+		AllowQueryParameter: opts.AllowQueryParameter,
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			compact, err := ExtractToken(r, extractOpts)
+			if err != nil {
+				if errors.Is(err, ErrNoToken) {
+					challenge(w, http.StatusUnauthorized, opts.Realm, "", "")
+				} else {
+					challenge(w, http.StatusBadRequest, opts.Realm, ErrorCodeInvalidRequest, "malformed bearer token request")
+				}
+				return
+			}
+
+			token, err := jwt.DecodeWithOptions(compact, opts.DecodeOptions)
+			if err == nil {
+				err = token.Verify(verifiers...)
+			}
+			if err != nil {
+				challenge(w, http.StatusUnauthorized, opts.Realm, ErrorCodeInvalidToken, errorDescription(err))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(ContextWithToken(r.Context(), token)))
+		})
+	}
+}
+
+// ExtractToken extracts the bearer token from r using the methods enabled
+// in opts. It returns an error wrapping ErrNoToken if r carries no token and
+// an error wrapping ErrInvalidRequest if r carries a malformed token or
+// uses more than one method to transmit a token as forbidden by RFC 6750
+// section 2.
+func ExtractToken(r *http.Request, opts ExtractOptions) (string, error) {
+	var tokens []string
+
+	if h := r.Header.Get("Authorization"); h != "" {
+		scheme, token, _ := strings.Cut(strings.TrimSpace(h), " ")
+		if strings.EqualFold(scheme, "Bearer") {
+			token = strings.TrimSpace(token)
+			if token == "" || strings.ContainsAny(token, " \t") {
+				return "", fmt.Errorf("%w: malformed Authorization header", ErrInvalidRequest)
+			}
+			tokens = append(tokens, token)
+		}
+	}
+
+	if opts.AllowFormParameter && r.Method != http.MethodGet && isFormEncoded(r) {
+		if err := r.ParseForm(); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+		}
+		tokens = append(tokens, r.PostForm[ParamAccessToken]...)
+	}
+
+	if opts.AllowQueryParameter {
+		tokens = append(tokens, r.URL.Query()[ParamAccessToken]...)
+	}
+
+	switch len(tokens) {
+	case 0:
+		return "", ErrNoToken
+	case 1:
+		return tokens[0], nil
+	default:
+		return "", fmt.Errorf("%w: multiple tokens", ErrInvalidRequest)
+	}
+}
+
+func isFormEncoded(r *http.Request) bool {
+	ct, _, _ := strings.Cut(r.Header.Get("Content-Type"), ";")
+	return strings.EqualFold(strings.TrimSpace(ct), "application/x-www-form-urlencoded")
+}
+
+// errorDescription returns the human readable description of err sent to
+// clients. The description does not reveal details beyond the failure's
+// category.
+func errorDescription(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired{}):
+		return "the access token expired"
+	case errors.Is(err, jwt.ErrTokenNotYetValid):
+		return "the access token is not yet valid"
+	case errors.Is(err, jwt.ErrInvalidToken):
+		return "the access token is malformed"
+	default:
+		return "the access token is invalid"
+	}
+}
+
+// challenge writes a response with the given status and a WWW-Authenticate
+// header as defined in RFC 6750 section 3.
+func challenge(w http.ResponseWriter, status int, realm, code, description string) {
+	var params []string
+	if realm != "" {
+		params = append(params, "realm="+quote(realm))
+	}
+	if code != "" {
+		params = append(params, "error="+quote(code))
+	}
+	if description != "" {
+		params = append(params, "error_description="+quote(description))
+	}
+
+	value := "Bearer"
+	if len(params) > 0 {
+		value += " " + strings.Join(params, ", ")
+	}
+
+	w.Header().Set("WWW-Authenticate", value)
+	w.Header().Set("Cache-Control", "no-store")
+	http.Error(w, http.StatusText(status), status)
+}
+
+// quote returns s as a quoted-string as defined in RFC 9110 section 5.6.4
+// (formerly RFC 7235). s must satisfy isQuotable.
+func quote(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// isQuotable reports whether s can be sent as a quoted-string, i.e. whether
+// it contains no control characters other than horizontal tab.
+func isQuotable(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if (s[i] < ' ' && s[i] != '\t') || s[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+type contextKey struct{}
+
+// ContextWithToken returns a new context derived from ctx carrying token.
+func ContextWithToken(ctx context.Context, token *jwt.Token) context.Context {
+	return context.WithValue(ctx, contextKey{}, token)
+}
+
+// TokenFromContext returns the token stored in ctx and true. If ctx carries
+// no token, nil and false are returned.
+func TokenFromContext(ctx context.Context) (*jwt.Token, bool) {
+	token, ok := ctx.Value(contextKey{}).(*jwt.Token)
+	return token, ok
+}
+
+// ClaimsFromContext returns the claims of the token stored in ctx decoded
+// as T and true. If ctx carries no token or the claims cannot be decoded
+// as T, the zero value and false are returned.
+func ClaimsFromContext[T any](ctx context.Context) (T, bool) {
+	var claims T
+
+	token, ok := TokenFromContext(ctx)
+	if !ok {
+		return claims, false
+	}
+
+	claims, err := jwt.ClaimsAs[T](token)
+	if err != nil {
+		return claims, false
+	}
+
+	return claims, true
+}
@@ -0,0 +1,204 @@
+package bearer
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/halimath/jose/jws"
+	"github.com/halimath/jose/jwt"
+)
+
+func TestMiddleware(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := jwt.FixedClock(now)
+	sig := jws.HS256([]byte("secret"))
+
+	sign := func(ttl time.Duration) string {
+		b := jwt.Builder{Signer: sig, TTL: ttl, Clock: clock}
+		token, err := b.Sign(jwt.StandardClaims{Subject: "john.doe"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token.Compact()
+	}
+
+	valid := sign(time.Hour)
+	expired := sign(-time.Hour)
+
+	handler := Middleware(Options{
+		Verifiers: []jwt.Verifier{
+			jwt.Signature(sig),
+			jwt.ExpirationTimeWithOptions(jwt.TimeOptions{Clock: clock}),
+		},
+		AllowFormParameter:  true,
+		AllowQueryParameter: true,
+		Realm:               "example",
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := ClaimsFromContext[jwt.StandardClaims](r.Context())
+		if !ok {
+			t.Error("expected claims in context")
+		}
+		w.Write([]byte(claims.Subject))
+	}))
+
+	formRequest := func(token string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(url.Values{ParamAccessToken: {token}}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return r
+	}
+
+	withHeader := func(r *http.Request, value string) *http.Request {
+		r.Header.Set("Authorization", value)
+		return r
+	}
+
+	tests := map[string]struct {
+		request   *http.Request
+		status    int
+		challenge string
+	}{
+		"header": {
+			withHeader(httptest.NewRequest(http.MethodGet, "/", nil), "Bearer "+valid),
+			http.StatusOK, "",
+		},
+		"header lower case scheme": {
+			withHeader(httptest.NewRequest(http.MethodGet, "/", nil), "bearer "+valid),
+			http.StatusOK, "",
+		},
+		"form": {
+			formRequest(valid),
+			http.StatusOK, "",
+		},
+		"query": {
+			httptest.NewRequest(http.MethodGet, "/?access_token="+valid, nil),
+			http.StatusOK, "",
+		},
+		"no token": {
+			httptest.NewRequest(http.MethodGet, "/", nil),
+			http.StatusUnauthorized, `Bearer realm="example"`,
+		},
+		"other scheme": {
+			withHeader(httptest.NewRequest(http.MethodGet, "/", nil), "Basic Zm9vOmJhcg=="),
+			http.StatusUnauthorized, `Bearer realm="example"`,
+		},
+		"multiple methods": {
+			withHeader(httptest.NewRequest(http.MethodGet, "/?access_token="+valid, nil), "Bearer "+valid),
+			http.StatusBadRequest, `Bearer realm="example", error="invalid_request", error_description="malformed bearer token request"`,
+		},
+		"malformed header": {
+			withHeader(httptest.NewRequest(http.MethodGet, "/", nil), "Bearer "),
+			http.StatusBadRequest, `Bearer realm="example", error="invalid_request", error_description="malformed bearer token request"`,
+		},
+		"bare scheme": {
+			withHeader(httptest.NewRequest(http.MethodGet, "/", nil), "Bearer"),
+			http.StatusBadRequest, `Bearer realm="example", error="invalid_request", error_description="malformed bearer token request"`,
+		},
+		"expired": {
+			withHeader(httptest.NewRequest(http.MethodGet, "/", nil), "Bearer "+expired),
+			http.StatusUnauthorized, `Bearer realm="example", error="invalid_token", error_description="the access token expired"`,
+		},
+		"invalid": {
+			withHeader(httptest.NewRequest(http.MethodGet, "/", nil), "Bearer foo.bar.baz"),
+			http.StatusUnauthorized, `Bearer realm="example", error="invalid_token", error_description="the access token is malformed"`,
+		},
+		"bad signature": {
+			withHeader(httptest.NewRequest(http.MethodGet, "/", nil), "Bearer "+valid[:len(valid)-2]+"AA"),
+			http.StatusUnauthorized, `Bearer realm="example", error="invalid_token", error_description="the access token is invalid"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, test.request)
+
+			if w.Code != test.status {
+				t.Errorf("expected status %d but got %d", test.status, w.Code)
+			}
+
+			if got := w.Header().Get("WWW-Authenticate"); got != test.challenge {
+				t.Errorf("expected challenge\n%s but got\n%s", test.challenge, got)
+			}
+
+			if test.status == http.StatusOK && w.Body.String() != "john.doe" {
+				t.Errorf("unexpected body: %q", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMiddleware_disabledMethods(t *testing.T) {
+	handler := Middleware(Options{
+		Verifiers: []jwt.Verifier{jwt.Signature(jws.HS256([]byte("secret")))},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?access_token=foo", nil))
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d but got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestMiddleware_quotedRealm(t *testing.T) {
+	handler := Middleware(Options{
+		Verifiers: []jwt.Verifier{jwt.Signature(jws.HS256([]byte("secret")))},
+		Realm:     `say "hello" \ world ü`,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	want := `Bearer realm="say \"hello\" \\ world ü"`
+	if got := w.Header().Get("WWW-Authenticate"); got != want {
+		t.Errorf("expected challenge\n%s but got\n%s", want, got)
+	}
+}
+
+func TestMiddleware_invalidOptions(t *testing.T) {
+	sig := jwt.Signature(jws.HS256([]byte("secret")))
+
+	tests := map[string]Options{
+		"no verifiers":  {},
+		"nil verifier":  {Verifiers: []jwt.Verifier{sig, nil}},
+		"invalid realm": {Verifiers: []jwt.Verifier{sig}, Realm: "line\nbreak"},
+	}
+
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			Middleware(opts)
+		})
+	}
+}
+
+func TestExtractToken(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/?"+ParamAccessToken+"=token", nil)
+
+	if _, err := ExtractToken(r, ExtractOptions{}); !errors.Is(err, ErrNoToken) {
+		t.Errorf("expected ErrNoToken but got %v", err)
+	}
+
+	token, err := ExtractToken(r, ExtractOptions{AllowQueryParameter: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "token" {
+		t.Errorf("unexpected token: %q", token)
+	}
+}
+
+func TestTokenFromContext_empty(t *testing.T) {
+	if _, ok := TokenFromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context()); ok {
+		t.Error("expected no token")
+	}
+}
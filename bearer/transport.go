@@ -0,0 +1,166 @@
+package bearer
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/halimath/jose/jwt"
+)
+
+// Transport is an http.RoundTripper that authenticates outgoing requests
+// using bearer tokens minted by Builder. A token is minted per audience and
+// reused until it is about to expire. Tokens are cached per audience;
+// entries for expired tokens are evicted whenever a token for a new audience
+// is minted, so the cache is bounded by the number of audiences contacted
+// within the token lifetime. Tokens without an expiration are cached for the
+// lifetime of the Transport, so a Builder without TTL should only be used
+// with a fixed set of audiences. A Transport is safe for concurrent use but
+// its fields must not be modified once it has been used.
+type Transport struct {
+	// Base is the RoundTripper used to send requests. If nil,
+	// http.DefaultTransport is used.
+	Base http.RoundTripper
+
+	// Builder mints the tokens. Its TTL should be set so that tokens expire.
+	// The Builder's Audience and an "aud" contained in Claims are replaced
+	// with the audience determined by Audience.
+	Builder *jwt.Builder
+
+	// Claims is the template of claims to include in each token. It must
+	// marshal to a JSON object. It may be nil.
+	Claims any
+
+	// Audience determines the audience of the token to send with a request.
+	// If nil, the request's host is used.
+	Audience func(r *http.Request) string
+
+	// RefreshBefore defines how long before a token's expiration a new token
+	// is minted. If zero, a new token is minted once a quarter of the
+	// Builder's TTL remains.
+	RefreshBefore time.Duration
+
+	mu     sync.Mutex
+	tokens map[string]*cachedToken
+}
+
+type cachedToken struct {
+	mu        sync.Mutex
+	compact   string
+	refreshAt time.Time
+
+	// expiresAt is guarded by Transport.mu.
+	expiresAt time.Time
+}
+
+// RoundTrip sends r with an Authorization header carrying a bearer token
+// for r's audience. Requests already carrying an Authorization header are
+// sent unchanged.
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if r.Header.Get("Authorization") != "" {
+		return base.RoundTrip(r)
+	}
+
+	aud := r.URL.Host
+	if t.Audience != nil {
+		aud = t.Audience(r)
+	}
+
+	token, err := t.token(aud)
+	if err != nil {
+		if r.Body != nil {
+			r.Body.Close()
+		}
+		return nil, err
+	}
+
+	r2 := r.Clone(r.Context())
+	r2.Header.Set("Authorization", "Bearer "+token)
+
+	return base.RoundTrip(r2)
+}
+
+// token returns the cached token for aud or mints a new one if none is
+// cached or the cached token is due for refresh.
+func (t *Transport) token(aud string) (string, error) {
+	t.mu.Lock()
+	if t.tokens == nil {
+		t.tokens = make(map[string]*cachedToken)
+	}
+	c, ok := t.tokens[aud]
+	if !ok {
+		t.evictExpired()
+		c = &cachedToken{}
+		t.tokens[aud] = c
+	}
+	t.mu.Unlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.compact != "" && (c.refreshAt.IsZero() || t.now().Before(c.refreshAt)) {
+		return c.compact, nil
+	}
+
+	compact, refreshAt, expiresAt, err := t.mint(aud)
+	if err != nil {
+		return "", err
+	}
+
+	c.compact = compact
+	c.refreshAt = refreshAt
+
+	t.mu.Lock()
+	c.expiresAt = expiresAt
+	t.mu.Unlock()
+
+	return compact, nil
+}
+
+// evictExpired removes the cached tokens that have expired. t.mu must be
+// held.
+func (t *Transport) evictExpired() {
+	now := t.now()
+	for aud, c := range t.tokens {
+		if !c.expiresAt.IsZero() && !now.Before(c.expiresAt) {
+			delete(t.tokens, aud)
+		}
+	}
+}
+
+// mint creates a new token for aud and returns it along with the times it
+// should be refreshed at and expires at. The zero time denotes a token that
+// never needs to be refreshed and never expires.
+func (t *Transport) mint(aud string) (string, time.Time, time.Time, error) {
+	token, err := t.Builder.SignWithOptions(t.Claims, jwt.SignOptions{Audience: jwt.Audiences{aud}})
+	if err != nil {
+		return "", time.Time{}, time.Time{}, fmt.Errorf("failed to mint bearer token for %s: %w", aud, err)
+	}
+
+	std := token.StandardClaims()
+	exp := std.GetExpirationTime()
+	if exp.IsZero() {
+		// Tokens without expiration never need to be refreshed
+		return token.Compact(), time.Time{}, time.Time{}, nil
+	}
+
+	refreshBefore := t.RefreshBefore
+	if refreshBefore == 0 {
+		refreshBefore = t.Builder.TTL / 4
+	}
+
+	return token.Compact(), exp.Add(-refreshBefore), exp, nil
+}
+
+func (t *Transport) now() time.Time {
+	if t.Builder.Clock == nil {
+		return jwt.SystemClock.Now()
+	}
+	return t.Builder.Clock.Now()
+}
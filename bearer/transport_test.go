@@ -0,0 +1,197 @@
+package bearer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/halimath/jose/jws"
+	"github.com/halimath/jose/jwt"
+)
+
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestTransport(t *testing.T) {
+	var mu sync.Mutex
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := jwt.ClockFunc(func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	})
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+
+	sig := jws.HS256([]byte("secret"))
+
+	var minted int32
+	transport := &Transport{
+		Base: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Header: r.Header.Clone(), Request: r}, nil
+		}),
+		Builder: &jwt.Builder{
+			Signer: sig,
+			TTL:    time.Minute,
+			Issuer: "client",
+			Clock:  clock,
+			IDGenerator: jwt.IDGeneratorFunc(func() (string, error) {
+				atomic.AddInt32(&minted, 1)
+				return "id", nil
+			}),
+		},
+		Claims: map[string]any{"sub": "service-a"},
+	}
+
+	send := func(url string) *jwt.Token {
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, url, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		compact := strings.TrimPrefix(res.Header.Get("Authorization"), "Bearer ")
+		token, err := jwt.Decode(compact)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	t.Run("claims", func(t *testing.T) {
+		token := send("http://service-b.example.com/")
+		if err := token.Verify(
+			jwt.Signature(sig),
+			jwt.Audience("service-b.example.com"),
+			jwt.Issuer("client"),
+			jwt.ExpirationTimeWithOptions(jwt.TimeOptions{Clock: clock}),
+		); err != nil {
+			t.Error(err)
+		}
+		if sub := token.StandardClaims().Subject; sub != "service-a" {
+			t.Errorf("unexpected sub: %q", sub)
+		}
+	})
+
+	t.Run("reuse and refresh", func(t *testing.T) {
+		atomic.StoreInt32(&minted, 0)
+
+		first := send("http://service-c.example.com/a")
+		if second := send("http://service-c.example.com/b"); second.Compact() != first.Compact() {
+			t.Error("expected token to be reused")
+		}
+
+		send("http://service-d.example.com/")
+		if n := atomic.LoadInt32(&minted); n != 2 {
+			t.Errorf("expected one token per audience but got %d", n)
+		}
+
+		// Within refresh window of a quarter TTL before expiry
+		advance(46 * time.Second)
+		if third := send("http://service-c.example.com/"); third.Compact() == first.Compact() {
+			t.Error("expected token to be refreshed")
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		atomic.StoreInt32(&minted, 0)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://service-e.example.com/", nil)); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if n := atomic.LoadInt32(&minted); n != 1 {
+			t.Errorf("expected a single token to be minted but got %d", n)
+		}
+	})
+
+	t.Run("eviction", func(t *testing.T) {
+		advance(2 * time.Minute)
+		send("http://service-f.example.com/")
+
+		transport.mu.Lock()
+		defer transport.mu.Unlock()
+
+		if len(transport.tokens) != 1 || transport.tokens["service-f.example.com"] == nil {
+			t.Errorf("expected expired tokens to be evicted but got %d cached tokens", len(transport.tokens))
+		}
+	})
+
+	t.Run("existing authorization", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "http://service-b.example.com/", nil)
+		r.Header.Set("Authorization", "Basic Zm9vOmJhcg==")
+		res, err := transport.RoundTrip(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := res.Header.Get("Authorization"); got != "Basic Zm9vOmJhcg==" {
+			t.Errorf("unexpected Authorization header: %q", got)
+		}
+	})
+}
+
+func TestTransport_largeIntegers(t *testing.T) {
+	var authorization string
+	transport := &Transport{
+		Base: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			authorization = r.Header.Get("Authorization")
+			return &http.Response{StatusCode: http.StatusOK, Request: r}, nil
+		}),
+		Builder: &jwt.Builder{Signer: jws.HS256([]byte("secret")), TTL: time.Minute},
+		Claims:  map[string]any{"account_id": int64(9007199254740993)},
+	}
+
+	if _, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://service.example.com/", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := jwt.Decode(strings.TrimPrefix(authorization, "Bearer "))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(token.Payload()), `"account_id":9007199254740993`) {
+		t.Errorf("expected integer to be preserved but got %s", token.Payload())
+	}
+}
+
+func TestTransport_withMiddleware(t *testing.T) {
+	sig := jws.HS256([]byte("secret"))
+
+	server := httptest.NewServer(Middleware(Options{
+		Verifiers: []jwt.Verifier{jwt.Signature(sig)},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &Transport{
+			Builder: &jwt.Builder{Signer: sig, TTL: time.Minute},
+		},
+	}
+
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d but got %d", http.StatusOK, res.StatusCode)
+	}
+}
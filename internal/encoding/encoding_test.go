@@ -20,3 +20,20 @@ func TestDecode(t *testing.T) {
 		t.Errorf("unexpected decoded string: '%s'", string(act))
 	}
 }
+
+func TestDecodeCanonical(t *testing.T) {
+	act, err := DecodeCanonical("aGVsbG8sIHdvcmxk")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(act) != "hello, world" {
+		t.Errorf("unexpected decoded string: '%s'", string(act))
+	}
+
+	for _, in := range []string{"aGl", "aGVsbG8s\nIHdvcmxk", "aGk="} {
+		if _, err := DecodeCanonical(in); err == nil {
+			t.Errorf("expected error decoding '%s'", in)
+		}
+	}
+}
@@ -3,7 +3,10 @@
 // (https://datatracker.ietf.org/doc/html/rfc7515#section-2)
 package encoding
 
-import "encoding/base64"
+import (
+	"encoding/base64"
+	"errors"
+)
 
 var (
 	enc = base64.URLEncoding.WithPadding(base64.NoPadding)
@@ -18,3 +21,25 @@ func Encode(data []byte) string {
 func Decode(data string) ([]byte, error) {
 	return enc.DecodeString(data)
 }
+
+// DecodeCanonical works like Decode but rejects any input that is not the
+// canonical encoding of the decoded data, i.e. input containing non-zero
+// padding bits or ignored characters such as line breaks.
+func DecodeCanonical(data string) ([]byte, error) {
+	b, err := enc.Strict().DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if Encode(b) != data {
+		return nil, errors.New("non-canonical base64url encoding")
+	}
+
+	return b, nil
+}
+
+// DecodedLen returns the maximum length in bytes of the data decoded from n
+// bytes of encoded data.
+func DecodedLen(n int) int {
+	return enc.DecodedLen(n)
+}
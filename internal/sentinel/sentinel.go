@@ -0,0 +1,32 @@
+// Package sentinel implements wrapping of errors with sentinel errors shared
+// by the jws and jwt packages.
+package sentinel
+
+import "errors"
+
+// wrapped wraps cause so that both cause and sentinel are reachable via
+// errors.Is and errors.As.
+type wrapped struct {
+	sentinel error
+	cause    error
+}
+
+func (e *wrapped) Error() string {
+	return e.sentinel.Error() + ": " + e.cause.Error()
+}
+
+func (e *wrapped) Unwrap() error {
+	return e.cause
+}
+
+func (e *wrapped) Is(target error) bool {
+	return target == e.sentinel
+}
+
+// Wrap wraps cause with sentinel unless cause already matches sentinel.
+func Wrap(sentinel, cause error) error {
+	if errors.Is(cause, sentinel) {
+		return cause
+	}
+	return &wrapped{sentinel: sentinel, cause: cause}
+}
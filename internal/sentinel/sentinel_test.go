@@ -0,0 +1,31 @@
+package sentinel
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	errSentinel := errors.New("sentinel")
+
+	err := Wrap(errSentinel, io.ErrUnexpectedEOF)
+
+	if !errors.Is(err, errSentinel) {
+		t.Error("expected error to match sentinel")
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Error("expected error to match cause")
+	}
+	if got := err.Error(); got != "sentinel: unexpected EOF" {
+		t.Errorf("unexpected message: %q", got)
+	}
+
+	t.Run("already wrapped", func(t *testing.T) {
+		cause := fmt.Errorf("%w: detail", errSentinel)
+		if got := Wrap(errSentinel, cause); got != cause {
+			t.Errorf("expected cause to be returned unchanged but got %v", got)
+		}
+	})
+}
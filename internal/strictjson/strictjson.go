@@ -0,0 +1,235 @@
+// Package strictjson implements checks for JSON documents that go beyond
+// the syntax checks performed by encoding/json.
+package strictjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrDuplicateMember is returned (maybe wrapped) from CheckDuplicateMembers.
+var ErrDuplicateMember = errors.New("duplicate member name")
+
+// CheckDuplicateMembers returns a non-nil error if data is not valid JSON or
+// if any object contained in data has two members with the same name. RFC
+// 7515 section 5.2 and RFC 7519 section 7.2 require such documents to be
+// rejected. encoding/json silently uses the last member instead.
+//
+// CheckDuplicateMembers does not decode any values; apart from the member
+// names of objects it does not allocate.
+func CheckDuplicateMembers(data []byte) error {
+	if !json.Valid(data) {
+		// Let encoding/json report the syntax error
+		var v any
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		return errors.New("invalid JSON")
+	}
+
+	s := scanner{data: data}
+	return s.value()
+}
+
+// scanner checks a valid JSON document for duplicate member names.
+type scanner struct {
+	data []byte
+	pos  int
+}
+
+func (s *scanner) skipSpace() {
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case ' ', '\t', '\r', '\n':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (s *scanner) value() error {
+	s.skipSpace()
+
+	switch s.data[s.pos] {
+	case '{':
+		s.pos++
+		var names map[string]struct{}
+		for {
+			s.skipSpace()
+			if s.data[s.pos] == '}' {
+				s.pos++
+				return nil
+			}
+
+			name, err := s.name()
+			if err != nil {
+				return err
+			}
+			if _, ok := names[name]; ok {
+				return fmt.Errorf("%w: %q", ErrDuplicateMember, name)
+			}
+			if names == nil {
+				names = make(map[string]struct{})
+			}
+			names[name] = struct{}{}
+
+			s.skipSpace()
+			s.pos++ // ':'
+
+			if err := s.value(); err != nil {
+				return err
+			}
+
+			s.skipSpace()
+			if s.data[s.pos] == ',' {
+				s.pos++
+			}
+		}
+
+	case '[':
+		s.pos++
+		for {
+			s.skipSpace()
+			if s.data[s.pos] == ']' {
+				s.pos++
+				return nil
+			}
+
+			if err := s.value(); err != nil {
+				return err
+			}
+
+			s.skipSpace()
+			if s.data[s.pos] == ',' {
+				s.pos++
+			}
+		}
+
+	case '"':
+		s.skipString()
+
+	default:
+		// Numbers and literals
+		for s.pos < len(s.data) {
+			switch s.data[s.pos] {
+			case ',', ']', '}', ' ', '\t', '\r', '\n':
+				return nil
+			}
+			s.pos++
+		}
+	}
+
+	return nil
+}
+
+// skipString advances past the string starting at s.pos and returns whether
+// it contains escape sequences.
+func (s *scanner) skipString() (escaped bool) {
+	s.pos++
+	for {
+		switch s.data[s.pos] {
+		case '\\':
+			escaped = true
+			s.pos += 2
+		case '"':
+			s.pos++
+			return
+		default:
+			s.pos++
+		}
+	}
+}
+
+// name returns the unescaped member name starting at s.pos.
+func (s *scanner) name() (string, error) {
+	start := s.pos
+	if !s.skipString() {
+		return string(s.data[start+1 : s.pos-1]), nil
+	}
+
+	var name string
+	err := json.Unmarshal(s.data[start:s.pos], &name)
+	return name, err
+}
+
+// DecodeObject decodes data, which must contain a JSON object, into a map
+// the same way json.Unmarshal does for a map[string]any. It returns a
+// non-nil error wrapping ErrDuplicateMember if any object contained in data
+// has two members with the same name. Checking for duplicates and decoding
+// is performed in a single pass over data.
+func DecodeObject(data []byte) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, errors.New("invalid JSON: not an object")
+	}
+
+	obj, err := decodeObject(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("invalid JSON: trailing data")
+	}
+
+	return obj, nil
+}
+
+// decodeObject decodes the members of an object whose opening delimiter has
+// already been consumed.
+func decodeObject(dec *json.Decoder) (map[string]any, error) {
+	obj := make(map[string]any)
+	for dec.More() {
+		nameTok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		name := nameTok.(string)
+		if _, ok := obj[name]; ok {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateMember, name)
+		}
+
+		v, err := decodeValue(dec)
+		if err != nil {
+			return nil, err
+		}
+		obj[name] = v
+	}
+	_, err := dec.Token()
+	return obj, err
+}
+
+func decodeValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		return decodeObject(dec)
+
+	case json.Delim('['):
+		arr := []any{}
+		for dec.More() {
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err = dec.Token()
+		return arr, err
+	}
+
+	return tok, nil
+}
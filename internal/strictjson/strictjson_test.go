@@ -0,0 +1,73 @@
+package strictjson
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestCheckDuplicateMembers(t *testing.T) {
+	for _, valid := range []string{
+		`{}`,
+		`{"a":1,"b":{"a":2},"c":[{"a":3},{"a":4}]}`,
+		` { "a" : "}\"" , "b\\" : [ 1 , -2.5e3 , true , null ] } `,
+		`"str"`,
+	} {
+		if err := CheckDuplicateMembers([]byte(valid)); err != nil {
+			t.Errorf("%s: %v", valid, err)
+		}
+	}
+
+	for _, dup := range []string{
+		`{"a":1,"a":2}`,
+		`{"a":{"b":1,"b":2}}`,
+		`[{"a":1,"a":1}]`,
+		`{"a":1,"\u0061":2}`,
+	} {
+		if err := CheckDuplicateMembers([]byte(dup)); !errors.Is(err, ErrDuplicateMember) {
+			t.Errorf("%s: expected ErrDuplicateMember but got %v", dup, err)
+		}
+	}
+
+	for _, invalid := range []string{`{"a":}`, `{} {}`, ``, `{"a`, `[1,`} {
+		if err := CheckDuplicateMembers([]byte(invalid)); err == nil {
+			t.Errorf("%s: expected error", invalid)
+		}
+	}
+}
+
+func TestDecodeObject(t *testing.T) {
+	const valid = `{"a":1.5,"b":{"c":[true,null,"s",{}]},"d":[]}`
+
+	got, err := DecodeObject([]byte(valid))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want map[string]any
+	if err := json.Unmarshal([]byte(valid), &want); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Error(diff)
+	}
+
+	for _, dup := range []string{
+		`{"a":1,"a":2}`,
+		`{"a":{"b":1,"b":2}}`,
+		`{"a":[{"b":1,"b":1}]}`,
+	} {
+		if _, err := DecodeObject([]byte(dup)); !errors.Is(err, ErrDuplicateMember) {
+			t.Errorf("%s: expected ErrDuplicateMember but got %v", dup, err)
+		}
+	}
+
+	for _, invalid := range []string{`[]`, `"str"`, `{"a":}`, `{} {}`, ``} {
+		if _, err := DecodeObject([]byte(invalid)); err == nil {
+			t.Errorf("%s: expected error", invalid)
+		}
+	}
+}
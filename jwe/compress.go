@@ -0,0 +1,79 @@
+package jwe
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+)
+
+// CompressionAlgorithm defines the type used to name algorithms compressing
+// the plaintext before encryption as defined in RFC 7516 section 4.1.3
+// (https://datatracker.ietf.org/doc/html/rfc7516#section-4.1.3)
+type CompressionAlgorithm string
+
+const (
+	// DEFLATE compression as defined in RFC 1951
+	// (https://datatracker.ietf.org/doc/html/rfc1951)
+	ZIP_DEFLATE CompressionAlgorithm = "DEF"
+)
+
+// DefaultMaxDecompressedSize is the default maximum size in bytes of a
+// decompressed plaintext.
+const DefaultMaxDecompressedSize = 1 << 20
+
+// compress compresses data using zip. If zip is empty, data is returned
+// unchanged.
+func compress(zip CompressionAlgorithm, data []byte) ([]byte, error) {
+	switch zip {
+	case "":
+		return data, nil
+
+	case ZIP_DEFLATE:
+		var buf bytes.Buffer
+		w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported compression algorithm: %s", zip)
+	}
+}
+
+// decompress decompresses data using zip reading at most maxSize bytes. If
+// zip is empty, data is returned unchanged.
+func decompress(zip CompressionAlgorithm, data []byte, maxSize int) ([]byte, error) {
+	switch zip {
+	case "":
+		return data, nil
+
+	case ZIP_DEFLATE:
+		r := flate.NewReader(bytes.NewReader(data))
+		defer r.Close()
+
+		decompressed, err := io.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress plaintext: %v", err)
+		}
+
+		if len(decompressed) > maxSize {
+			return nil, fmt.Errorf("decompressed plaintext exceeds maximum size of %d bytes", maxSize)
+		}
+
+		return decompressed, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported compression algorithm: %s", zip)
+	}
+}
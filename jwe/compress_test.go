@@ -0,0 +1,104 @@
+package jwe
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncryptDecrypt_deflate(t *testing.T) {
+	km, err := A128KW([]byte("0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := strings.Repeat("hello, world ", 100)
+
+	uncompressed, err := Encrypt(km, ENC_A128GCM, []byte(payload), Header{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compressed, err := Encrypt(km, ENC_A128GCM, []byte(payload), Header{Compression: ZIP_DEFLATE})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(compressed.ciphertext) >= len(uncompressed.ciphertext) {
+		t.Errorf("expected compressed ciphertext to be shorter: %d vs. %d", len(compressed.ciphertext), len(uncompressed.ciphertext))
+	}
+
+	parsed, err := ParseCompact(compressed.Compact())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.Header().Compression != ZIP_DEFLATE {
+		t.Errorf("unexpected zip header: %q", parsed.Header().Compression)
+	}
+
+	t.Run("decrypt", func(t *testing.T) {
+		plaintext, err := parsed.Decrypt(km)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(plaintext) != payload {
+			t.Errorf("corrupted payload: %q", string(plaintext))
+		}
+	})
+
+	t.Run("exceeds max size", func(t *testing.T) {
+		_, err := parsed.DecryptWithOptions(km, DecryptOptions{MaxDecompressedSize: len(payload) - 1})
+		if !errors.Is(err, ErrDecryptionFailed) {
+			t.Errorf("expected ErrDecryptionFailed but got %v", err)
+		}
+	})
+}
+
+func TestEncrypt_unsupportedCompression(t *testing.T) {
+	km, err := A128KW([]byte("0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Encrypt(km, ENC_A128GCM, []byte("hello, world"), Header{Compression: "LZ4"}); err == nil {
+		t.Error("expected error but got nil")
+	}
+}
+
+func TestEncryptJSON_deflate(t *testing.T) {
+	km, err := A128KW([]byte("0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := strings.Repeat("hello, world ", 100)
+
+	t.Run("protected", func(t *testing.T) {
+		j, err := EncryptJSON(ENC_A128GCM, []byte(payload), Header{Compression: ZIP_DEFLATE}, Header{}, nil, Recipient{KeyWrapper: km})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		plaintext, err := j.Decrypt(km)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(plaintext) != payload {
+			t.Errorf("corrupted payload: %q", string(plaintext))
+		}
+
+		if _, err := j.DecryptWithOptions(km, DecryptOptions{MaxDecompressedSize: 10}); !errors.Is(err, ErrDecryptionFailed) {
+			t.Errorf("expected ErrDecryptionFailed but got %v", err)
+		}
+	})
+
+	t.Run("unprotected", func(t *testing.T) {
+		_, err := EncryptJSON(ENC_A128GCM, []byte(payload), Header{}, Header{Compression: ZIP_DEFLATE}, nil, Recipient{KeyWrapper: km})
+		if !errors.Is(err, ErrInvalidHeader) {
+			t.Errorf("expected ErrInvalidHeader but got %v", err)
+		}
+	})
+}
@@ -0,0 +1,252 @@
+package jwe
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+// ContentEncryptionAlgorithm defines the type used to name algorithms
+// encrypting and integrity protecting the plaintext as defined in
+// RFC 7518 section 5.1
+// (https://www.rfc-editor.org/rfc/rfc7518.html#section-5.1)
+type ContentEncryptionAlgorithm string
+
+const (
+	// AES_128_CBC_HMAC_SHA_256 authenticated encryption algorithm
+	ENC_A128CBC_HS256 ContentEncryptionAlgorithm = "A128CBC-HS256"
+
+	// AES_192_CBC_HMAC_SHA_384 authenticated encryption algorithm
+	ENC_A192CBC_HS384 ContentEncryptionAlgorithm = "A192CBC-HS384"
+
+	// AES_256_CBC_HMAC_SHA_512 authenticated encryption algorithm
+	ENC_A256CBC_HS512 ContentEncryptionAlgorithm = "A256CBC-HS512"
+
+	// AES GCM using 128-bit key
+	ENC_A128GCM ContentEncryptionAlgorithm = "A128GCM"
+
+	// AES GCM using 192-bit key
+	ENC_A192GCM ContentEncryptionAlgorithm = "A192GCM"
+
+	// AES GCM using 256-bit key
+	ENC_A256GCM ContentEncryptionAlgorithm = "A256GCM"
+)
+
+// KeySize returns the size of the content encryption key used by e in bytes.
+// It returns 0 if e is not a supported content encryption algorithm.
+func (e ContentEncryptionAlgorithm) KeySize() int {
+	switch e {
+	case ENC_A128CBC_HS256:
+		return 32
+	case ENC_A192CBC_HS384:
+		return 48
+	case ENC_A256CBC_HS512:
+		return 64
+	case ENC_A128GCM:
+		return 16
+	case ENC_A192GCM:
+		return 24
+	case ENC_A256GCM:
+		return 32
+	default:
+		return 0
+	}
+}
+
+// UsesGCM returns true if e utilizes AES in Galois/Counter Mode.
+func (e ContentEncryptionAlgorithm) UsesGCM() bool {
+	switch e {
+	case ENC_A128GCM, ENC_A192GCM, ENC_A256GCM:
+		return true
+	default:
+		return false
+	}
+}
+
+var errAuthenticationFailed = errors.New("authentication tag mismatch")
+
+// generateKey creates a new random content encryption key for enc.
+func generateKey(enc ContentEncryptionAlgorithm) ([]byte, error) {
+	size := enc.KeySize()
+	if size == 0 {
+		return nil, fmt.Errorf("unsupported content encryption algorithm: %s", enc)
+	}
+
+	cek := make([]byte, size)
+	if _, err := rand.Read(cek); err != nil {
+		return nil, err
+	}
+
+	return cek, nil
+}
+
+func encryptContent(enc ContentEncryptionAlgorithm, cek, plaintext, aad []byte) (iv, ciphertext, tag []byte, err error) {
+	if len(cek) != enc.KeySize() || len(cek) == 0 {
+		return nil, nil, nil, fmt.Errorf("invalid content encryption key size for %s: %d", enc, len(cek))
+	}
+
+	if enc.UsesGCM() {
+		return encryptGCM(cek, plaintext, aad)
+	}
+
+	return encryptCBCHMAC(enc, cek, plaintext, aad)
+}
+
+func decryptContent(enc ContentEncryptionAlgorithm, cek, iv, ciphertext, tag, aad []byte) ([]byte, error) {
+	if len(cek) != enc.KeySize() || len(cek) == 0 {
+		return nil, fmt.Errorf("invalid content encryption key size for %s: %d", enc, len(cek))
+	}
+
+	if enc.UsesGCM() {
+		return decryptGCM(cek, iv, ciphertext, tag, aad)
+	}
+
+	return decryptCBCHMAC(enc, cek, iv, ciphertext, tag, aad)
+}
+
+// --
+
+func encryptGCM(cek, plaintext, aad []byte) (iv, ciphertext, tag []byte, err error) {
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	iv = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return nil, nil, nil, err
+	}
+
+	sealed := aead.Seal(nil, iv, plaintext, aad)
+	n := len(sealed) - aead.Overhead()
+
+	return iv, sealed[:n], sealed[n:], nil
+}
+
+func decryptGCM(cek, iv, ciphertext, tag, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(iv) != aead.NonceSize() || len(tag) != aead.Overhead() {
+		return nil, errAuthenticationFailed
+	}
+
+	sealed := make([]byte, 0, len(ciphertext)+len(tag))
+	sealed = append(sealed, ciphertext...)
+	sealed = append(sealed, tag...)
+
+	plaintext, err := aead.Open(nil, iv, sealed, aad)
+	if err != nil {
+		return nil, errAuthenticationFailed
+	}
+
+	return plaintext, nil
+}
+
+// --
+
+// cbcHMACHash returns the hash function used to compute the authentication
+// tag for the AES_CBC_HMAC_SHA2 algorithm enc as defined in RFC 7518 section 5.2
+// (https://www.rfc-editor.org/rfc/rfc7518.html#section-5.2)
+func cbcHMACHash(enc ContentEncryptionAlgorithm) func() hash.Hash {
+	switch enc {
+	case ENC_A128CBC_HS256:
+		return sha256.New
+	case ENC_A192CBC_HS384:
+		return sha512.New384
+	default:
+		return sha512.New
+	}
+}
+
+func cbcHMACTag(enc ContentEncryptionAlgorithm, macKey, iv, ciphertext, aad []byte) []byte {
+	var al [8]byte
+	binary.BigEndian.PutUint64(al[:], uint64(len(aad))*8)
+
+	mac := hmac.New(cbcHMACHash(enc), macKey)
+	mac.Write(aad)
+	mac.Write(iv)
+	mac.Write(ciphertext)
+	mac.Write(al[:])
+
+	return mac.Sum(nil)[:len(macKey)]
+}
+
+func encryptCBCHMAC(enc ContentEncryptionAlgorithm, cek, plaintext, aad []byte) (iv, ciphertext, tag []byte, err error) {
+	macKey := cek[:len(cek)/2]
+	encKey := cek[len(cek)/2:]
+
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	iv = make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, nil, nil, err
+	}
+
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	ciphertext = make([]byte, len(plaintext)+padding)
+	copy(ciphertext, plaintext)
+	for i := len(plaintext); i < len(ciphertext); i++ {
+		ciphertext[i] = byte(padding)
+	}
+
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, ciphertext)
+
+	return iv, ciphertext, cbcHMACTag(enc, macKey, iv, ciphertext, aad), nil
+}
+
+func decryptCBCHMAC(enc ContentEncryptionAlgorithm, cek, iv, ciphertext, tag, aad []byte) ([]byte, error) {
+	macKey := cek[:len(cek)/2]
+	encKey := cek[len(cek)/2:]
+
+	if subtle.ConstantTimeCompare(tag, cbcHMACTag(enc, macKey, iv, ciphertext, aad)) != 1 {
+		return nil, errAuthenticationFailed
+	}
+
+	if len(iv) != aes.BlockSize || len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("invalid ciphertext length: %d", len(ciphertext))
+	}
+
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return nil, errors.New("invalid padding")
+	}
+	for _, b := range plaintext[len(plaintext)-padding:] {
+		if int(b) != padding {
+			return nil, errors.New("invalid padding")
+		}
+	}
+
+	return plaintext[:len(plaintext)-padding], nil
+}
@@ -0,0 +1,33 @@
+package jwe_test
+
+import (
+	"fmt"
+
+	"github.com/halimath/jose/jwe"
+)
+
+func Example() {
+	keyManager := jwe.PBES2HS256A128KW([]byte("secret"))
+
+	encrypted, err := jwe.Encrypt(keyManager, jwe.ENC_A128CBC_HS256, []byte("hello, world"), jwe.Header{})
+	if err != nil {
+		panic(err)
+	}
+
+	compact := encrypted.Compact()
+
+	encrypted2, err := jwe.ParseCompact(compact)
+	if err != nil {
+		panic(err)
+	}
+
+	plaintext, err := encrypted2.Decrypt(keyManager)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(string(plaintext))
+
+	// Output:
+	// hello, world
+}
@@ -0,0 +1,434 @@
+package jwe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/halimath/jose/internal/encoding"
+	"github.com/halimath/jose/jwk"
+)
+
+// ErrInvalidJSONJWE is returned when a given byte slice is not a valid JWE in JSON serialized form.
+var ErrInvalidJSONJWE = errors.New("invalid JSON JWE")
+
+// Recipient defines a single recipient of a JWE using the JSON serialization.
+type Recipient struct {
+	// KeyWrapper is used to encrypt the shared content encryption key for
+	// this recipient.
+	KeyWrapper KeyWrapper
+
+	// Header contains the per-recipient unprotected header parameters. Its
+	// "alg" parameter is set from KeyWrapper.
+	Header Header
+}
+
+type jsonRecipient struct {
+	header              Header
+	encryptedKey        []byte
+	encryptedKeyEncoded string
+}
+
+// JSONJWE implements a JWE using the JSON serialization as specified in
+// RFC 7516 section 7.2 (https://datatracker.ietf.org/doc/html/rfc7516#section-7.2).
+// It supports multiple recipients sharing the same encrypted content. Once
+// created a JSONJWE is immutable. A JSONJWE may only be created through
+// functions exposed from this package, i.e.
+//
+//	func EncryptJSON(enc ContentEncryptionAlgorithm, plaintext []byte, protected, unprotected Header, aad []byte, recipients ...Recipient) (*JSONJWE, error)
+//	func ParseJSON(data []byte) (*JSONJWE, error)
+type JSONJWE struct {
+	protected                   Header
+	protectedEncoded            string
+	unprotected                 Header
+	recipients                  []jsonRecipient
+	aad                         []byte
+	aadEncoded                  string
+	initializationVector        []byte
+	initializationVectorEncoded string
+	ciphertext                  []byte
+	ciphertextEncoded           string
+	tag                         []byte
+	tagEncoded                  string
+}
+
+// ProtectedHeader returns a copy of j's integrity protected header.
+func (j *JSONJWE) ProtectedHeader() Header {
+	return j.protected
+}
+
+// UnprotectedHeader returns a copy of j's shared unprotected header.
+func (j *JSONJWE) UnprotectedHeader() Header {
+	return j.unprotected
+}
+
+// AAD returns a copy of j's additional authenticated data.
+func (j *JSONJWE) AAD() []byte {
+	if j.aad == nil {
+		return nil
+	}
+	b := make([]byte, len(j.aad))
+	copy(b, j.aad)
+	return b
+}
+
+// Recipients returns the joint header for each of j's recipients. The joint
+// header is the union of the protected, the shared unprotected and the
+// per-recipient header.
+func (j *JSONJWE) Recipients() []Header {
+	headers := make([]Header, len(j.recipients))
+	for i := range j.recipients {
+		// The joint headers have been verified to be valid on creation
+		headers[i], _ = j.jointHeader(i)
+	}
+	return headers
+}
+
+// Decrypt decrypts j's content using decrypter. It tries each recipient
+// whose joint header matches decrypter and returns the plaintext for the
+// first recipient which can be decrypted. A recipient matches if its "alg"
+// equals the algorithm implemented by decrypter and, for decrypters created
+// by KeySetDecrypter, a key usable for the recipient's "kid" and "alg" is
+// contained in the set. Decrypters which do not expose their algorithm
+// match every recipient. If j contains more than DefaultMaxRecipients
+// recipients or no recipient can be decrypted, a non-nil error wrapping
+// ErrDecryptionFailed is returned.
+func (j *JSONJWE) Decrypt(decrypter KeyDecrypter) ([]byte, error) {
+	return j.DecryptWithOptions(decrypter, DecryptOptions{})
+}
+
+// DecryptWithOptions works like Decrypt but applies opts.
+func (j *JSONJWE) DecryptWithOptions(decrypter KeyDecrypter, opts DecryptOptions) ([]byte, error) {
+	if max := opts.maxRecipients(); len(j.recipients) > max {
+		return nil, fmt.Errorf("%w: %d recipients exceed maximum of %d", ErrDecryptionFailed, len(j.recipients), max)
+	}
+
+	var lastErr error = errors.New("no matching recipient")
+
+	for i, r := range j.recipients {
+		header, err := j.jointHeader(i)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if !matchesRecipient(decrypter, header) {
+			continue
+		}
+
+		cek, err := decrypter.DecryptKey(header, r.encryptedKey)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		plaintext, err := decryptContent(header.Encryption, cek, j.initializationVector, j.ciphertext, j.tag, j.additionalAuthenticatedData())
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		plaintext, err = decompress(j.protected.Compression, plaintext, opts.maxDecompressedSize())
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrDecryptionFailed, err)
+		}
+
+		return plaintext, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrDecryptionFailed, lastErr)
+}
+
+// recipientMatcher is implemented by KeyDecrypters that can tell whether
+// they are able to decrypt the key for a recipient without trying to do so.
+type recipientMatcher interface {
+	matchesRecipient(header Header) bool
+}
+
+// matchesRecipient reports whether decrypter may be able to decrypt the key
+// of the recipient described by header.
+func matchesRecipient(decrypter KeyDecrypter, header Header) bool {
+	switch d := decrypter.(type) {
+	case recipientMatcher:
+		return d.matchesRecipient(header)
+	case interface{ Alg() KeyManagementAlgorithm }:
+		return d.Alg() == header.Algorithm
+	}
+	return true
+}
+
+// DecryptWithKeySet decrypts j's content using a key from set. It is a
+// shortcut for calling Decrypt with KeySetDecrypter(set).
+func (j *JSONJWE) DecryptWithKeySet(set jwk.Set) ([]byte, error) {
+	return j.Decrypt(KeySetDecrypter(set))
+}
+
+// additionalAuthenticatedData returns the input to the content encryption's
+// additional authenticated data as defined in RFC 7516 section 5.1 step 14
+// (https://datatracker.ietf.org/doc/html/rfc7516#section-5.1).
+func (j *JSONJWE) additionalAuthenticatedData() []byte {
+	if j.aad == nil {
+		return []byte(j.protectedEncoded)
+	}
+	return []byte(j.protectedEncoded + "." + j.aadEncoded)
+}
+
+func (j *JSONJWE) jointHeader(i int) (Header, error) {
+	return jointHeader(j.protected, j.unprotected, j.recipients[i].header)
+}
+
+type jsonRecipientWire struct {
+	Header       *Header `json:"header,omitempty"`
+	EncryptedKey string  `json:"encrypted_key,omitempty"`
+}
+
+type jsonWire struct {
+	Protected    string              `json:"protected,omitempty"`
+	Unprotected  *Header             `json:"unprotected,omitempty"`
+	Recipients   []jsonRecipientWire `json:"recipients,omitempty"`
+	Header       *Header             `json:"header,omitempty"`
+	EncryptedKey string              `json:"encrypted_key,omitempty"`
+	AAD          string              `json:"aad,omitempty"`
+	IV           string              `json:"iv"`
+	Ciphertext   string              `json:"ciphertext"`
+	Tag          string              `json:"tag"`
+}
+
+// MarshalJSON returns j in general JWE JSON serialization as defined in
+// RFC 7516 section 7.2.1 (https://datatracker.ietf.org/doc/html/rfc7516#section-7.2.1).
+func (j *JSONJWE) MarshalJSON() ([]byte, error) {
+	w := jsonWire{
+		Protected:  j.protectedEncoded,
+		Recipients: make([]jsonRecipientWire, len(j.recipients)),
+		AAD:        j.aadEncoded,
+		IV:         j.initializationVectorEncoded,
+		Ciphertext: j.ciphertextEncoded,
+		Tag:        j.tagEncoded,
+	}
+
+	if j.unprotected != (Header{}) {
+		unprotected := j.unprotected
+		w.Unprotected = &unprotected
+	}
+
+	for i, r := range j.recipients {
+		w.Recipients[i].EncryptedKey = r.encryptedKeyEncoded
+		if r.header != (Header{}) {
+			header := r.header
+			w.Recipients[i].Header = &header
+		}
+	}
+
+	return json.Marshal(w)
+}
+
+// ParseJSON parses the given JWE in either general or flattened JSON
+// serialization as defined in RFC 7516 section 7.2
+// (https://datatracker.ietf.org/doc/html/rfc7516#section-7.2). It performs
+// only a syntactically validation of base64 URL encoded data as well as
+// parsing the headers. The content is NOT decrypted. Use Decrypt to perform
+// the decryption.
+func ParseJSON(data []byte) (*JSONJWE, error) {
+	var w jsonWire
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidJSONJWE, err)
+	}
+
+	if w.Recipients != nil && (w.Header != nil || w.EncryptedKey != "") {
+		return nil, fmt.Errorf("%w: mixed general and flattened syntax", ErrInvalidJSONJWE)
+	}
+
+	if w.Recipients == nil {
+		w.Recipients = []jsonRecipientWire{{Header: w.Header, EncryptedKey: w.EncryptedKey}}
+	}
+
+	if len(w.Recipients) == 0 {
+		return nil, fmt.Errorf("%w: no recipients", ErrInvalidJSONJWE)
+	}
+
+	j := JSONJWE{
+		protectedEncoded:            w.Protected,
+		aadEncoded:                  w.AAD,
+		initializationVectorEncoded: w.IV,
+		ciphertextEncoded:           w.Ciphertext,
+		tagEncoded:                  w.Tag,
+		recipients:                  make([]jsonRecipient, len(w.Recipients)),
+	}
+
+	if w.Protected != "" {
+		protected, err := DecodeHeader(w.Protected)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidJSONJWE, err)
+		}
+		j.protected = *protected
+	}
+
+	if w.Unprotected != nil {
+		j.unprotected = *w.Unprotected
+	}
+
+	if j.unprotected.Compression != "" {
+		return nil, fmt.Errorf("%w: zip header must be integrity protected", ErrInvalidJSONJWE)
+	}
+
+	var err error
+
+	if w.AAD != "" {
+		if j.aad, err = encoding.Decode(w.AAD); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidJSONJWE, err)
+		}
+	}
+
+	if j.initializationVector, err = encoding.Decode(w.IV); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidJSONJWE, err)
+	}
+
+	if j.ciphertext, err = encoding.Decode(w.Ciphertext); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidJSONJWE, err)
+	}
+
+	if j.tag, err = encoding.Decode(w.Tag); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidJSONJWE, err)
+	}
+
+	for i, r := range w.Recipients {
+		if r.Header != nil {
+			j.recipients[i].header = *r.Header
+		}
+
+		j.recipients[i].encryptedKeyEncoded = r.EncryptedKey
+		if j.recipients[i].encryptedKey, err = encoding.Decode(r.EncryptedKey); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidJSONJWE, err)
+		}
+
+		if j.recipients[i].header.Compression != "" {
+			return nil, fmt.Errorf("%w: zip header must be integrity protected", ErrInvalidJSONJWE)
+		}
+
+		header, err := j.jointHeader(i)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidJSONJWE, err)
+		}
+
+		if header.Algorithm == "" || header.Encryption == "" {
+			return nil, fmt.Errorf("%w: recipient %d is missing alg or enc header", ErrInvalidJSONJWE, i)
+		}
+	}
+
+	return &j, nil
+}
+
+// EncryptJSON encrypts plaintext using the content encryption algorithm enc
+// for all of the given recipients. The "enc" parameter is added to the
+// protected header. unprotected is used as the shared unprotected header and
+// aad as additional authenticated data; both may be empty. The header
+// parameter names used in protected, unprotected and each recipient's header
+// must be disjoint. If protected contains a "zip" parameter, plaintext is
+// compressed before encryption. "zip" must not be used in unprotected or
+// per-recipient headers.
+func EncryptJSON(enc ContentEncryptionAlgorithm, plaintext []byte, protected, unprotected Header, aad []byte, recipients ...Recipient) (*JSONJWE, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients")
+	}
+
+	if unprotected.Compression != "" {
+		return nil, fmt.Errorf("%w: zip header must be integrity protected", ErrInvalidHeader)
+	}
+
+	plaintext, err := compress(protected.Compression, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	cek, err := generateKey(enc)
+	if err != nil {
+		return nil, err
+	}
+
+	protected.Encryption = enc
+
+	j := JSONJWE{
+		protected:        protected,
+		protectedEncoded: protected.Encode(),
+		unprotected:      unprotected,
+		recipients:       make([]jsonRecipient, len(recipients)),
+	}
+
+	if len(aad) > 0 {
+		j.aad = aad
+		j.aadEncoded = encoding.Encode(aad)
+	}
+
+	for i, r := range recipients {
+		header := r.Header
+		header.Algorithm = r.KeyWrapper.Alg()
+
+		if header.Compression != "" {
+			return nil, fmt.Errorf("%w: zip header must be integrity protected", ErrInvalidHeader)
+		}
+
+		encryptedKey, err := r.KeyWrapper.WrapKey(cek, &header)
+		if err != nil {
+			return nil, err
+		}
+
+		j.recipients[i] = jsonRecipient{
+			header:              header,
+			encryptedKey:        encryptedKey,
+			encryptedKeyEncoded: encoding.Encode(encryptedKey),
+		}
+
+		if _, err := j.jointHeader(i); err != nil {
+			return nil, err
+		}
+	}
+
+	j.initializationVector, j.ciphertext, j.tag, err = encryptContent(enc, cek, plaintext, j.additionalAuthenticatedData())
+	if err != nil {
+		return nil, err
+	}
+
+	j.initializationVectorEncoded = encoding.Encode(j.initializationVector)
+	j.ciphertextEncoded = encoding.Encode(j.ciphertext)
+	j.tagEncoded = encoding.Encode(j.tag)
+
+	return &j, nil
+}
+
+// jointHeader computes the union of the given headers as defined in RFC 7516
+// section 7.2.1. It returns a non-nil error wrapping ErrInvalidHeader if the
+// headers share a parameter name.
+func jointHeader(headers ...Header) (Header, error) {
+	merged := make(map[string]json.RawMessage)
+
+	for _, h := range headers {
+		data, err := json.Marshal(h)
+		if err != nil {
+			return Header{}, fmt.Errorf("%w: %s", ErrInvalidHeader, err)
+		}
+
+		var params map[string]json.RawMessage
+		if err := json.Unmarshal(data, &params); err != nil {
+			return Header{}, fmt.Errorf("%w: %s", ErrInvalidHeader, err)
+		}
+
+		for name, value := range params {
+			if _, ok := merged[name]; ok {
+				return Header{}, fmt.Errorf("%w: duplicate header parameter: %s", ErrInvalidHeader, name)
+			}
+			merged[name] = value
+		}
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return Header{}, fmt.Errorf("%w: %s", ErrInvalidHeader, err)
+	}
+
+	var joint Header
+	if err := json.Unmarshal(data, &joint); err != nil {
+		return Header{}, fmt.Errorf("%w: %s", ErrInvalidHeader, err)
+	}
+
+	return joint, nil
+}
@@ -0,0 +1,301 @@
+package jwe
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/halimath/jose/jwk"
+)
+
+func TestEncryptJSON_multipleRecipients(t *testing.T) {
+	kek := []byte("0123456789abcdef")
+	aesKW, err := A128KW(kek)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pbes2 := PBES2HS256A128KW([]byte("secret"))
+	pbes2.Count = 1000
+
+	const payload = "hello, world"
+
+	j, err := EncryptJSON(ENC_A128CBC_HS256, []byte(payload),
+		Header{ContentType: "text/plain"},
+		Header{Type: "example"},
+		[]byte("additional data"),
+		Recipient{KeyWrapper: aesKW, Header: Header{KeyID: "1"}},
+		Recipient{KeyWrapper: pbes2, Header: Header{KeyID: "2"}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParseJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(j, parsed); diff != nil {
+		t.Error(diff)
+	}
+
+	recipients := parsed.Recipients()
+	if len(recipients) != 2 {
+		t.Fatalf("expected 2 recipients but got %d", len(recipients))
+	}
+
+	if diff := deep.Equal(Header{
+		Algorithm:   ALG_A128KW,
+		Encryption:  ENC_A128CBC_HS256,
+		Type:        "example",
+		ContentType: "text/plain",
+		KeyID:       "1",
+	}, recipients[0]); diff != nil {
+		t.Error(diff)
+	}
+
+	t.Run("key manager", func(t *testing.T) {
+		for _, decrypter := range []KeyDecrypter{aesKW, pbes2} {
+			plaintext, err := parsed.Decrypt(decrypter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(plaintext) != payload {
+				t.Errorf("corrupted payload: %q != %q", payload, string(plaintext))
+			}
+		}
+	})
+
+	t.Run("key set", func(t *testing.T) {
+		set := jwk.Set{
+			&jwk.SymmetricKey{
+				KeyDescription: jwk.KeyDescription{KeyID: "0"},
+				Bytes:          []byte("fedcba9876543210"),
+			},
+			&jwk.SymmetricKey{
+				KeyDescription: jwk.KeyDescription{KeyID: "1", KeyUse: jwk.UseEncryption},
+				Bytes:          kek,
+			},
+		}
+
+		plaintext, err := parsed.DecryptWithKeySet(set)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(plaintext) != payload {
+			t.Errorf("corrupted payload: %q != %q", payload, string(plaintext))
+		}
+	})
+
+	t.Run("no matching key", func(t *testing.T) {
+		set := jwk.Set{
+			&jwk.SymmetricKey{
+				KeyDescription: jwk.KeyDescription{KeyID: "1", KeyUse: jwk.UseSignature},
+				Bytes:          kek,
+			},
+		}
+
+		if _, err := parsed.DecryptWithKeySet(set); !errors.Is(err, ErrDecryptionFailed) {
+			t.Errorf("expected ErrDecryptionFailed but got %v", err)
+		}
+	})
+
+	t.Run("tampered aad", func(t *testing.T) {
+		tampered := *parsed
+		tampered.aadEncoded = "dGFtcGVyZWQ"
+
+		if _, err := tampered.Decrypt(aesKW); !errors.Is(err, ErrDecryptionFailed) {
+			t.Errorf("expected ErrDecryptionFailed but got %v", err)
+		}
+	})
+}
+
+func TestEncryptJSON_duplicateHeader(t *testing.T) {
+	aesKW, err := A128KW([]byte("0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = EncryptJSON(ENC_A128GCM, []byte("hello, world"),
+		Header{KeyID: "1"},
+		Header{},
+		nil,
+		Recipient{KeyWrapper: aesKW, Header: Header{KeyID: "1"}},
+	)
+	if !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("expected ErrInvalidHeader but got %v", err)
+	}
+}
+
+func TestParseJSON_flattened(t *testing.T) {
+	aesKW, err := A128KW([]byte("0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	j, err := EncryptJSON(ENC_A128GCM, []byte("hello, world"), Header{}, Header{}, nil, Recipient{KeyWrapper: aesKW})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flattened, err := json.Marshal(map[string]any{
+		"protected":     j.protectedEncoded,
+		"header":        j.recipients[0].header,
+		"encrypted_key": j.recipients[0].encryptedKeyEncoded,
+		"iv":            j.initializationVectorEncoded,
+		"ciphertext":    j.ciphertextEncoded,
+		"tag":           j.tagEncoded,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParseJSON(flattened)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext, err := parsed.Decrypt(aesKW)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(plaintext) != "hello, world" {
+		t.Errorf("corrupted payload: %q", string(plaintext))
+	}
+}
+
+func TestParseJSON_invalid(t *testing.T) {
+	inputs := []string{
+		`[]`,
+		`{"recipients":[],"iv":"","ciphertext":"","tag":""}`,
+		`{"header":{"alg":"A128KW"},"iv":"","ciphertext":"","tag":""}`,
+		`{"protected":"eyJlbmMiOiJBMTI4R0NNIn0","header":{"alg":"A128KW","enc":"A128GCM"},"iv":"","ciphertext":"","tag":""}`,
+	}
+
+	for _, in := range inputs {
+		if _, err := ParseJSON([]byte(in)); !errors.Is(err, ErrInvalidJSONJWE) {
+			t.Errorf("%s: expected ErrInvalidJSONJWE but got %v", in, err)
+		}
+	}
+}
+
+type countingDecrypter struct {
+	*PBES2KeyManager
+	calls int
+}
+
+func (c *countingDecrypter) DecryptKey(header Header, encryptedKey []byte) ([]byte, error) {
+	c.calls++
+	return c.PBES2KeyManager.DecryptKey(header, encryptedKey)
+}
+
+func TestJSONJWE_DecryptWithOptions_recipients(t *testing.T) {
+	kek := []byte("0123456789abcdef")
+	aesKW, err := A128KW(kek)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pbes2 := PBES2HS256A128KW([]byte("secret"))
+	pbes2.Count = 1000
+
+	encrypt := func(recipients ...Recipient) *JSONJWE {
+		j, err := EncryptJSON(ENC_A128GCM, []byte("hello, world"), Header{}, Header{}, nil, recipients...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return j
+	}
+
+	t.Run("only matching recipients", func(t *testing.T) {
+		j := encrypt(
+			Recipient{KeyWrapper: aesKW, Header: Header{KeyID: "1"}},
+			Recipient{KeyWrapper: aesKW, Header: Header{KeyID: "2"}},
+			Recipient{KeyWrapper: pbes2, Header: Header{KeyID: "3"}},
+		)
+
+		d := &countingDecrypter{PBES2KeyManager: pbes2}
+		if _, err := j.Decrypt(d); err != nil {
+			t.Fatal(err)
+		}
+		if d.calls != 1 {
+			t.Errorf("expected a single recipient to be tried but got %d", d.calls)
+		}
+	})
+
+	t.Run("too many recipients", func(t *testing.T) {
+		recipients := make([]Recipient, DefaultMaxRecipients+1)
+		for i := range recipients {
+			recipients[i] = Recipient{KeyWrapper: pbes2}
+		}
+		j := encrypt(recipients...)
+
+		d := &countingDecrypter{PBES2KeyManager: pbes2}
+		if _, err := j.Decrypt(d); !errors.Is(err, ErrDecryptionFailed) {
+			t.Errorf("expected ErrDecryptionFailed but got %v", err)
+		}
+		if d.calls != 0 {
+			t.Errorf("expected no recipient to be tried but got %d", d.calls)
+		}
+
+		if _, err := j.DecryptWithOptions(d, DecryptOptions{MaxRecipients: len(recipients)}); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestKeySetDecrypter_maxCandidates(t *testing.T) {
+	kek := []byte("0123456789abcdef")
+	aesKW, err := A128KW(kek)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := jwk.Set{}
+	for i := 0; i < DefaultMaxKeyCandidates; i++ {
+		set = append(set, &jwk.SymmetricKey{
+			KeyDescription: jwk.KeyDescription{KeyID: string(rune('a' + i))},
+			Bytes:          []byte("fedcba9876543210"),
+		})
+	}
+	set = append(set, &jwk.SymmetricKey{
+		KeyDescription: jwk.KeyDescription{KeyID: "kek"},
+		Bytes:          kek,
+	})
+
+	t.Run("without kid", func(t *testing.T) {
+		j, err := EncryptJSON(ENC_A128GCM, []byte("hello, world"), Header{}, Header{}, nil, Recipient{KeyWrapper: aesKW})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := j.DecryptWithKeySet(set); !errors.Is(err, ErrDecryptionFailed) {
+			t.Errorf("expected ErrDecryptionFailed but got %v", err)
+		}
+
+		if _, err := j.Decrypt(KeySetDecrypterWithOptions(set, KeySetDecrypterOptions{MaxCandidates: len(set)})); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("with kid", func(t *testing.T) {
+		j, err := EncryptJSON(ENC_A128GCM, []byte("hello, world"), Header{}, Header{}, nil, Recipient{KeyWrapper: aesKW, Header: Header{KeyID: "kek"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := j.DecryptWithKeySet(set); err != nil {
+			t.Error(err)
+		}
+	})
+}
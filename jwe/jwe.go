@@ -0,0 +1,348 @@
+// Package jwe contains an implementation of JSON Web Encryption (jwe) as
+// defined in RFC 7516 (https://datatracker.ietf.org/doc/html/rfc7516) as well
+// as the key management and content encryption algorithms from JSON Web
+// Algorithms (jwa) as defined in RFC 7518
+// (https://www.rfc-editor.org/rfc/rfc7518.html) which are needed to use it.
+package jwe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/halimath/jose/internal/encoding"
+)
+
+var (
+	// ErrInvalidCompactJWE is returned when a given string is not a valid JWE in compact serialized form.
+	ErrInvalidCompactJWE = errors.New("invalid compact JWE")
+
+	// ErrInvalidHeader is returned (maybe wrapped) when a JWE's protected header cannot be decoded.
+	ErrInvalidHeader = errors.New("invalid header")
+
+	// ErrDecryptionFailed is returned when either the content encryption key or the content
+	// itself cannot be decrypted.
+	ErrDecryptionFailed = errors.New("decryption failed")
+)
+
+// --
+
+// Header defines the structure representing a JWE JOSE header as defined in RFC 7516 section 4
+// (https://datatracker.ietf.org/doc/html/rfc7516#section-4). This implementation has no support
+// for private header parameters.
+type Header struct {
+	Algorithm   KeyManagementAlgorithm     `json:"alg,omitempty"`
+	Encryption  ContentEncryptionAlgorithm `json:"enc,omitempty"`
+	Type        string                     `json:"typ,omitempty"`
+	ContentType string                     `json:"cty,omitempty"`
+	KeyID       string                     `json:"kid,omitempty"`
+	Compression CompressionAlgorithm       `json:"zip,omitempty"`
+
+	// The base64url encoded PBES2 salt input as defined in RFC 7518 section 4.8.1.1
+	// (https://www.rfc-editor.org/rfc/rfc7518.html#section-4.8.1.1)
+	PBES2SaltInput string `json:"p2s,omitempty"`
+
+	// The PBES2 iteration count as defined in RFC 7518 section 4.8.1.2
+	// (https://www.rfc-editor.org/rfc/rfc7518.html#section-4.8.1.2)
+	PBES2Count int `json:"p2c,omitempty"`
+
+	// The base64url encoded KEM ciphertext used by the ML-KEM key management
+	// algorithms.
+	EncapsulatedKey string `json:"ek,omitempty"`
+}
+
+func (h *Header) Encode() string {
+	b, err := json.Marshal(*h)
+	if err != nil {
+		panic(err)
+	}
+
+	return encoding.Encode(b)
+}
+
+func DecodeHeader(encoded string) (*Header, error) {
+	b, err := encoding.Decode(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidHeader, err)
+	}
+
+	var h Header
+	err = json.Unmarshal(b, &h)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidHeader, err)
+	}
+
+	return &h, nil
+}
+
+// --
+
+// JWE implements a JSON Web Encryption datastructure. The fields
+// of this struct represent the different components of a JWE in
+// multiple ways. Once created a JWE is immutable. A JWE may only
+// be created through functions exposed from this package, i.e.
+//
+//	func Encrypt(encrypter KeyEncrypter, enc ContentEncryptionAlgorithm, plaintext []byte, header Header) (*JWE, error)
+//	func ParseCompact(compact string) (*JWE, error)
+type JWE struct {
+	header                      Header
+	headerEncoded               string
+	encryptedKey                []byte
+	encryptedKeyEncoded         string
+	initializationVector        []byte
+	initializationVectorEncoded string
+	ciphertext                  []byte
+	ciphertextEncoded           string
+	tag                         []byte
+	tagEncoded                  string
+}
+
+// Header returns a copy of j's header.
+func (j *JWE) Header() Header {
+	return j.header
+}
+
+// Compact returns the JWE in compact serialization as specified in
+// RFC 7516 section 7.1
+// (https://datatracker.ietf.org/doc/html/rfc7516#section-7.1)
+func (j *JWE) Compact() string {
+	return j.headerEncoded + "." +
+		j.encryptedKeyEncoded + "." +
+		j.initializationVectorEncoded + "." +
+		j.ciphertextEncoded + "." +
+		j.tagEncoded
+}
+
+// DefaultMaxRecipients is the default maximum number of recipients of a JWE
+// in JSON serialization accepted when decrypting.
+const DefaultMaxRecipients = 4
+
+// DecryptOptions defines options to control decryption.
+type DecryptOptions struct {
+	// MaxDecompressedSize is the maximum size in bytes of a plaintext after
+	// decompression. It protects against memory exhaustion caused by hostile
+	// tokens. If MaxDecompressedSize is less than or equal to zero
+	// DefaultMaxDecompressedSize is used.
+	MaxDecompressedSize int
+
+	// MaxRecipients is the maximum number of recipients a JWE in JSON
+	// serialization may contain to be decrypted. It protects against CPU
+	// exhaustion caused by hostile tokens carrying many recipients using
+	// expensive key management algorithms such as PBES2. If MaxRecipients is
+	// less than or equal to zero DefaultMaxRecipients is used.
+	MaxRecipients int
+}
+
+func (o DecryptOptions) maxDecompressedSize() int {
+	if o.MaxDecompressedSize <= 0 {
+		return DefaultMaxDecompressedSize
+	}
+	return o.MaxDecompressedSize
+}
+
+func (o DecryptOptions) maxRecipients() int {
+	if o.MaxRecipients <= 0 {
+		return DefaultMaxRecipients
+	}
+	return o.MaxRecipients
+}
+
+// Decrypt decrypts the content encryption key using decrypter and uses the
+// key to decrypt and authenticate j's ciphertext. It returns the plaintext
+// or a non-nil error wrapping ErrDecryptionFailed.
+func (j *JWE) Decrypt(decrypter KeyDecrypter) ([]byte, error) {
+	return j.DecryptWithOptions(decrypter, DecryptOptions{})
+}
+
+// DecryptWithOptions works like Decrypt but applies opts.
+func (j *JWE) DecryptWithOptions(decrypter KeyDecrypter, opts DecryptOptions) ([]byte, error) {
+	cek, err := decrypter.DecryptKey(j.header, j.encryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecryptionFailed, err)
+	}
+
+	plaintext, err := decryptContent(j.header.Encryption, cek, j.initializationVector, j.ciphertext, j.tag, []byte(j.headerEncoded))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecryptionFailed, err)
+	}
+
+	plaintext, err = decompress(j.header.Compression, plaintext, opts.maxDecompressedSize())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecryptionFailed, err)
+	}
+
+	return plaintext, nil
+}
+
+// Encrypt encrypts the given plaintext using the content encryption algorithm
+// enc. The content encryption key is produced by encrypter which also sets
+// the key management specific header parameters. If header contains a "zip"
+// parameter, plaintext is compressed before encryption. It returns a JWE value
+// containing the raw and encoded parts.
+func Encrypt(encrypter KeyEncrypter, enc ContentEncryptionAlgorithm, plaintext []byte, header Header) (*JWE, error) {
+	if enc.KeySize() == 0 {
+		return nil, fmt.Errorf("unsupported content encryption algorithm: %s", enc)
+	}
+
+	plaintext, err := compress(header.Compression, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	header.Algorithm = encrypter.Alg()
+	header.Encryption = enc
+
+	cek, encryptedKey, err := encrypter.EncryptKey(enc, &header)
+	if err != nil {
+		return nil, err
+	}
+
+	headerEncoded := header.Encode()
+
+	iv, ciphertext, tag, err := encryptContent(enc, cek, plaintext, []byte(headerEncoded))
+	if err != nil {
+		return nil, err
+	}
+
+	return &JWE{
+		header:                      header,
+		headerEncoded:               headerEncoded,
+		encryptedKey:                encryptedKey,
+		encryptedKeyEncoded:         encoding.Encode(encryptedKey),
+		initializationVector:        iv,
+		initializationVectorEncoded: encoding.Encode(iv),
+		ciphertext:                  ciphertext,
+		ciphertextEncoded:           encoding.Encode(ciphertext),
+		tag:                         tag,
+		tagEncoded:                  encoding.Encode(tag),
+	}, nil
+}
+
+// ParseCompact parses the given compact representation into a JWE datastructure and returns it.
+// It performs only a syntactically validation of base64 URL encoded data as well as parsing
+// the JOSE header JSON. The content is NOT decrypted. Use Decrypt to perform the decryption.
+func ParseCompact(compact string) (*JWE, error) {
+	parts := strings.Split(compact, ".")
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("%w: invalid number of encoded parts: %d", ErrInvalidCompactJWE, len(parts))
+	}
+
+	header, err := DecodeHeader(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCompactJWE, err)
+	}
+
+	decoded := make([][]byte, 4)
+	for i, p := range parts[1:] {
+		decoded[i], err = encoding.Decode(p)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCompactJWE, err)
+		}
+	}
+
+	return &JWE{
+		header:                      *header,
+		headerEncoded:               parts[0],
+		encryptedKey:                decoded[0],
+		encryptedKeyEncoded:         parts[1],
+		initializationVector:        decoded[1],
+		initializationVectorEncoded: parts[2],
+		ciphertext:                  decoded[2],
+		ciphertextEncoded:           parts[3],
+		tag:                         decoded[3],
+		tagEncoded:                  parts[4],
+	}, nil
+}
+
+// KeyManagementAlgorithm defines the type used to name algorithms determining
+// the content encryption key as defined in RFC 7518 section 4.1
+// (https://www.rfc-editor.org/rfc/rfc7518.html#section-4.1)
+type KeyManagementAlgorithm string
+
+const (
+	// AES Key Wrap with default initial value using 128-bit key
+	ALG_A128KW KeyManagementAlgorithm = "A128KW"
+
+	// AES Key Wrap with default initial value using 192-bit key
+	ALG_A192KW KeyManagementAlgorithm = "A192KW"
+
+	// AES Key Wrap with default initial value using 256-bit key
+	ALG_A256KW KeyManagementAlgorithm = "A256KW"
+
+	// PBES2 with HMAC SHA-256 and "A128KW" wrapping
+	ALG_PBES2_HS256_A128KW KeyManagementAlgorithm = "PBES2-HS256+A128KW"
+
+	// PBES2 with HMAC SHA-384 and "A192KW" wrapping
+	ALG_PBES2_HS384_A192KW KeyManagementAlgorithm = "PBES2-HS384+A192KW"
+
+	// PBES2 with HMAC SHA-512 and "A256KW" wrapping
+	ALG_PBES2_HS512_A256KW KeyManagementAlgorithm = "PBES2-HS512+A256KW"
+)
+
+// The ML-KEM based key management algorithms follow the IETF JOSE drafts
+// for post-quantum key encapsulation. As these drafts have not been
+// finalized, the algorithms are only used when explicitly requested using
+// the identifiers below. The shared secret produced by the KEM is passed
+// through the Concat KDF as defined in RFC 7518 section 4.6.2
+// (https://www.rfc-editor.org/rfc/rfc7518.html#section-4.6.2) and the KEM
+// ciphertext is transported in the "ek" header parameter. The algorithms
+// require go1.24 or later; when built with earlier versions, MLKEMEncrypter,
+// MLKEMDecrypter and KeyDecrypterFromJWK return an error for them.
+const (
+	// ML-KEM-768 with direct use of the derived key as the content encryption key
+	ALG_MLKEM768 KeyManagementAlgorithm = "MLKEM768"
+
+	// ML-KEM-1024 with direct use of the derived key as the content encryption key
+	ALG_MLKEM1024 KeyManagementAlgorithm = "MLKEM1024"
+
+	// ML-KEM-768 with "A192KW" wrapping using the derived key
+	ALG_MLKEM768_A192KW KeyManagementAlgorithm = "MLKEM768+A192KW"
+
+	// ML-KEM-1024 with "A256KW" wrapping using the derived key
+	ALG_MLKEM1024_A256KW KeyManagementAlgorithm = "MLKEM1024+A256KW"
+)
+
+// KeyEncrypter defines the interface for types implementing a key management
+// algorithm on the producing side of a JWE.
+type KeyEncrypter interface {
+	// Alg returns the name of the key management algorithm as defined in
+	// RFC 7518 section 4.1
+	// (https://www.rfc-editor.org/rfc/rfc7518.html#section-4.1)
+	Alg() KeyManagementAlgorithm
+
+	// EncryptKey determines the content encryption key to use with enc and
+	// returns it along with the encrypted key to put into the JWE. Algorithm
+	// specific header parameters are set on header.
+	EncryptKey(enc ContentEncryptionAlgorithm, header *Header) (cek []byte, encryptedKey []byte, err error)
+}
+
+// KeyWrapper defines the interface for key management algorithms that encrypt
+// a given content encryption key. Only such algorithms can be used to encrypt
+// a JWE for multiple recipients, as all recipients share the same content
+// encryption key.
+type KeyWrapper interface {
+	KeyEncrypter
+
+	// WrapKey encrypts cek and returns the encrypted key to put into the JWE.
+	// Algorithm specific header parameters are set on header.
+	WrapKey(cek []byte, header *Header) ([]byte, error)
+}
+
+// KeyDecrypter defines the interface for types implementing a key management
+// algorithm on the consuming side of a JWE.
+type KeyDecrypter interface {
+	// DecryptKey is called to determine the content encryption key from the
+	// given header and encrypted key. Implementations return a non-nil error
+	// if the key cannot be determined. Implementations MUST NOT modify
+	// encryptedKey.
+	DecryptKey(header Header, encryptedKey []byte) ([]byte, error)
+}
+
+// KeyEncrypterDecrypter is the combination of both KeyEncrypter and
+// KeyDecrypter. It is used for key management algorithms based on a shared
+// secret.
+type KeyEncrypterDecrypter interface {
+	KeyEncrypter
+	KeyDecrypter
+}
@@ -0,0 +1,107 @@
+package jwe
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestHeader(t *testing.T) {
+	h := Header{
+		Algorithm:      ALG_PBES2_HS256_A128KW,
+		Encryption:     ENC_A128CBC_HS256,
+		ContentType:    "jwk+json",
+		PBES2SaltInput: "2WCTcJZ1Rvd_CJuJripQ1w",
+		PBES2Count:     4096,
+	}
+
+	encoded := h.Encode()
+	decoded, err := DecodeHeader(encoded)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(h, *decoded); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestEncryptParseDecrypt(t *testing.T) {
+	encs := []ContentEncryptionAlgorithm{
+		ENC_A128CBC_HS256,
+		ENC_A192CBC_HS384,
+		ENC_A256CBC_HS512,
+		ENC_A128GCM,
+		ENC_A192GCM,
+		ENC_A256GCM,
+	}
+
+	km := PBES2HS256A128KW([]byte("secret"))
+	km.Count = 1000
+
+	payloads := []string{"", "hello, world", "0123456789abcdef"}
+
+	for _, enc := range encs {
+		t.Run(string(enc), func(t *testing.T) {
+			for _, payload := range payloads {
+				j, err := Encrypt(km, enc, []byte(payload), Header{ContentType: "text/plain"})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				parsed, err := ParseCompact(j.Compact())
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if diff := deep.Equal(j, parsed); diff != nil {
+					t.Error(diff)
+				}
+
+				plaintext, err := parsed.Decrypt(km)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if payload != string(plaintext) {
+					t.Errorf("corrupted payload: %q != %q", payload, string(plaintext))
+				}
+			}
+		})
+	}
+}
+
+func TestDecrypt_tamperedHeader(t *testing.T) {
+	km := PBES2HS256A128KW([]byte("secret"))
+	km.Count = 1000
+
+	j, err := Encrypt(km, ENC_A128CBC_HS256, []byte("hello, world"), Header{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := j.Header()
+	h.Type = "JWT"
+	j.headerEncoded = h.Encode()
+	j.header = h
+
+	if _, err := j.Decrypt(km); !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("expected ErrDecryptionFailed but got %v", err)
+	}
+}
+
+func TestParseCompact_invalidParts(t *testing.T) {
+	inputs := []string{
+		"",
+		"eyJhbGciOiJub25lIn0.aGVsbG8sIHdvcmxk.",
+		"a.b.c.d.e.f",
+	}
+
+	for _, in := range inputs {
+		if _, err := ParseCompact(in); !errors.Is(err, ErrInvalidCompactJWE) {
+			t.Errorf("%q: expected ErrInvalidCompactJWE but got %v", in, err)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package jwe
+
+import (
+	"fmt"
+
+	"github.com/halimath/jose/jwk"
+)
+
+// KeyDecrypterFromJWK creates a KeyDecrypter implementing alg using the key
+// material from key. It returns a non-nil error if key is not usable for
+// alg, i.e. because key is of an unsupported type, its "use" is not "enc",
+// its "key_ops" contain neither "decrypt" nor "unwrapKey" or its "alg"
+// denotes a different algorithm. Errors caused by the key's parameters wrap
+// jwk.ErrUnsuitableKey.
+func KeyDecrypterFromJWK(key jwk.Key, alg KeyManagementAlgorithm) (KeyDecrypter, error) {
+	if err := checkDecryptionKey(key, alg); err != nil {
+		return nil, err
+	}
+
+	switch k := key.(type) {
+	case *jwk.SymmetricKey:
+		if alg == ALG_A128KW || alg == ALG_A192KW || alg == ALG_A256KW {
+			return AESKW(alg, k.Bytes)
+		}
+		return PBES2(alg, k.Bytes)
+
+	case *jwk.AKPKey:
+		return keyDecrypterFromAKPKey(k, alg)
+	}
+
+	return nil, fmt.Errorf("unsupported key type %s for key management algorithm %s", key.Type(), alg)
+}
+
+// checkDecryptionKey checks that key may be used to decrypt a content
+// encryption key using alg without creating the KeyDecrypter, which is
+// expensive for some key types.
+func checkDecryptionKey(key jwk.Key, alg KeyManagementAlgorithm) error {
+	if key.Use() != "" && key.Use() != jwk.UseEncryption {
+		return fmt.Errorf("%w: key %q is not intended for encryption: %s", jwk.ErrUnsuitableKey, key.ID(), key.Use())
+	}
+
+	if ops := key.Operations(); len(ops) > 0 && !containsOp(ops, jwk.KeyOpsDecrypt) && !containsOp(ops, jwk.KeyOpsUnwrapKey) {
+		return fmt.Errorf("%w: key %q permits neither %s nor %s", jwk.ErrUnsuitableKey, key.ID(), jwk.KeyOpsDecrypt, jwk.KeyOpsUnwrapKey)
+	}
+
+	if key.Algorithm() != "" && key.Algorithm() != string(alg) {
+		return fmt.Errorf("%w: key %q is not intended for %s: %s", jwk.ErrUnsuitableKey, key.ID(), alg, key.Algorithm())
+	}
+
+	switch key.(type) {
+	case *jwk.SymmetricKey:
+		switch alg {
+		case ALG_A128KW, ALG_A192KW, ALG_A256KW, ALG_PBES2_HS256_A128KW, ALG_PBES2_HS384_A192KW, ALG_PBES2_HS512_A256KW:
+			return nil
+		}
+
+	case *jwk.AKPKey:
+		switch alg {
+		case ALG_MLKEM768, ALG_MLKEM768_A192KW, ALG_MLKEM1024, ALG_MLKEM1024_A256KW:
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unsupported key type %s for key management algorithm %s", key.Type(), alg)
+}
+
+func containsOp(ops []jwk.KeyOp, op jwk.KeyOp) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultMaxKeyCandidates is the default maximum number of keys a
+// KeySetDecrypter tries for a header without a "kid" parameter.
+const DefaultMaxKeyCandidates = 4
+
+// KeySetDecrypterOptions defines the options for a KeySetDecrypter. The zero
+// value uses DefaultMaxKeyCandidates.
+type KeySetDecrypterOptions struct {
+	// MaxCandidates is the maximum number of keys usable for a header's "alg"
+	// that are tried if the header contains no "kid" parameter. If more keys
+	// are usable, decryption fails without trying any key. It protects
+	// against CPU exhaustion caused by hostile tokens using expensive key
+	// management algorithms such as PBES2. If MaxCandidates is less than or
+	// equal to zero DefaultMaxKeyCandidates is used.
+	MaxCandidates int
+}
+
+// KeySetDecrypter creates a KeyDecrypter that uses the keys from set. If the
+// header passed to DecryptKey contains a "kid" parameter, only the key with
+// the same ID is used. Otherwise all keys usable for the header's "alg" are
+// tried in order as long as there are no more than DefaultMaxKeyCandidates
+// such keys.
+func KeySetDecrypter(set jwk.Set) KeyDecrypter {
+	return KeySetDecrypterWithOptions(set, KeySetDecrypterOptions{})
+}
+
+// KeySetDecrypterWithOptions works like KeySetDecrypter but applies opts.
+func KeySetDecrypterWithOptions(set jwk.Set, opts KeySetDecrypterOptions) KeyDecrypter {
+	if opts.MaxCandidates <= 0 {
+		opts.MaxCandidates = DefaultMaxKeyCandidates
+	}
+	return &keySetDecrypter{set: set, opts: opts}
+}
+
+type keySetDecrypter struct {
+	set  jwk.Set
+	opts KeySetDecrypterOptions
+}
+
+// candidates returns the keys usable for header along with the error of the
+// last unusable key. The keys' decrypters are created only when used, so
+// that expensive keys such as ML-KEM decapsulation keys are derived once per
+// recipient.
+func (k *keySetDecrypter) candidates(header Header) ([]jwk.Key, error) {
+	var candidates []jwk.Key
+	var lastErr error = fmt.Errorf("no key found for kid %q", header.KeyID)
+
+	for _, key := range k.set {
+		if header.KeyID != "" && key.ID() != header.KeyID {
+			continue
+		}
+
+		if err := checkDecryptionKey(key, header.Algorithm); err != nil {
+			lastErr = err
+			continue
+		}
+
+		candidates = append(candidates, key)
+	}
+
+	return candidates, lastErr
+}
+
+func (k *keySetDecrypter) matchesRecipient(header Header) bool {
+	candidates, _ := k.candidates(header)
+	return len(candidates) > 0
+}
+
+func (k *keySetDecrypter) DecryptKey(header Header, encryptedKey []byte) ([]byte, error) {
+	candidates, lastErr := k.candidates(header)
+
+	if header.KeyID == "" && len(candidates) > k.opts.MaxCandidates {
+		return nil, fmt.Errorf("%d keys are usable for %s exceeding maximum of %d; a kid header is required", len(candidates), header.Algorithm, k.opts.MaxCandidates)
+	}
+
+	for _, key := range candidates {
+		decrypter, err := KeyDecrypterFromJWK(key, header.Algorithm)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		cek, err := decrypter.DecryptKey(header, encryptedKey)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		return cek, nil
+	}
+
+	return nil, lastErr
+}
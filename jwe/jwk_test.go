@@ -0,0 +1,43 @@
+package jwe
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/halimath/jose/jwk"
+)
+
+func TestKeyDecrypterFromJWK(t *testing.T) {
+	kek := []byte("0123456789abcdef")
+
+	tests := []struct {
+		name string
+		desc jwk.KeyDescription
+		ok   bool
+	}{
+		{name: "no restrictions", ok: true},
+		{name: "use enc", desc: jwk.KeyDescription{KeyUse: jwk.UseEncryption}, ok: true},
+		{name: "use sig", desc: jwk.KeyDescription{KeyUse: jwk.UseSignature}},
+		{name: "key_ops unwrapKey", desc: jwk.KeyDescription{KeyOperations: []jwk.KeyOp{jwk.KeyOpsUnwrapKey}}, ok: true},
+		{name: "key_ops decrypt", desc: jwk.KeyDescription{KeyOperations: []jwk.KeyOp{jwk.KeyOpsDecrypt}}, ok: true},
+		{name: "key_ops wrapKey only", desc: jwk.KeyDescription{KeyOperations: []jwk.KeyOp{jwk.KeyOpsEncrypt, jwk.KeyOpsKeyWrap}}},
+		{name: "matching alg", desc: jwk.KeyDescription{KeyAlgorithm: string(ALG_A128KW)}, ok: true},
+		{name: "other alg", desc: jwk.KeyDescription{KeyAlgorithm: string(ALG_A256KW)}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := KeyDecrypterFromJWK(&jwk.SymmetricKey{KeyDescription: test.desc, Bytes: kek}, ALG_A128KW)
+			if test.ok {
+				if err != nil {
+					t.Error(err)
+				}
+				return
+			}
+
+			if !errors.Is(err, jwk.ErrUnsuitableKey) {
+				t.Errorf("expected ErrUnsuitableKey but got %v", err)
+			}
+		})
+	}
+}
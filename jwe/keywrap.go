@@ -0,0 +1,178 @@
+package jwe
+
+import (
+	"crypto/aes"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// keyWrapDefaultIV is the default initial value defined in RFC 3394 section 2.2.3.1
+// (https://datatracker.ietf.org/doc/html/rfc3394#section-2.2.3.1)
+var keyWrapDefaultIV = []byte{0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6}
+
+var errKeyUnwrapFailed = errors.New("key unwrap failed: integrity check failed")
+
+// keyWrap wraps key with kek using the AES Key Wrap algorithm as specified
+// in RFC 3394 (https://datatracker.ietf.org/doc/html/rfc3394).
+func keyWrap(kek, key []byte) ([]byte, error) {
+	if len(key) < 16 || len(key)%8 != 0 {
+		return nil, fmt.Errorf("invalid key size for key wrap: %d", len(key))
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(key) / 8
+	out := make([]byte, len(key)+8)
+	copy(out, keyWrapDefaultIV)
+	copy(out[8:], key)
+
+	var buf [16]byte
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(buf[:8], out[:8])
+			copy(buf[8:], out[i*8:(i+1)*8])
+			block.Encrypt(buf[:], buf[:])
+
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(out[:8], binary.BigEndian.Uint64(buf[:8])^t)
+			copy(out[i*8:], buf[8:])
+		}
+	}
+
+	return out, nil
+}
+
+// keyUnwrap unwraps wrapped with kek using the AES Key Wrap algorithm as specified
+// in RFC 3394 (https://datatracker.ietf.org/doc/html/rfc3394).
+func keyUnwrap(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < 24 || len(wrapped)%8 != 0 {
+		return nil, fmt.Errorf("invalid wrapped key size: %d", len(wrapped))
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(wrapped)/8 - 1
+	out := make([]byte, len(wrapped))
+	copy(out, wrapped)
+
+	var buf [16]byte
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(buf[:8], binary.BigEndian.Uint64(out[:8])^t)
+			copy(buf[8:], out[i*8:(i+1)*8])
+			block.Decrypt(buf[:], buf[:])
+
+			copy(out[:8], buf[:8])
+			copy(out[i*8:], buf[8:])
+		}
+	}
+
+	if subtle.ConstantTimeCompare(out[:8], keyWrapDefaultIV) != 1 {
+		return nil, errKeyUnwrapFailed
+	}
+
+	return out[8:], nil
+}
+
+// --
+
+// AESKWKeyManager implements the AES Key Wrap key management algorithms
+// using a pre-shared key encryption key as defined in RFC 7518 section 4.4
+// (https://www.rfc-editor.org/rfc/rfc7518.html#section-4.4)
+type AESKWKeyManager struct {
+	alg KeyManagementAlgorithm
+	kek []byte
+}
+
+func (a *AESKWKeyManager) Alg() KeyManagementAlgorithm {
+	return a.alg
+}
+
+func (a *AESKWKeyManager) EncryptKey(enc ContentEncryptionAlgorithm, header *Header) ([]byte, []byte, error) {
+	cek, err := generateKey(enc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encryptedKey, err := a.WrapKey(cek, header)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cek, encryptedKey, nil
+}
+
+func (a *AESKWKeyManager) WrapKey(cek []byte, header *Header) ([]byte, error) {
+	return keyWrap(a.kek, cek)
+}
+
+func (a *AESKWKeyManager) DecryptKey(header Header, encryptedKey []byte) ([]byte, error) {
+	if header.Algorithm != a.alg {
+		return nil, fmt.Errorf("key management algorithms do not match: %s vs. %s", a.alg, header.Algorithm)
+	}
+
+	cek, err := keyUnwrap(a.kek, encryptedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cek) != header.Encryption.KeySize() {
+		return nil, fmt.Errorf("invalid content encryption key size for %s: %d", header.Encryption, len(cek))
+	}
+
+	return cek, nil
+}
+
+// AESKW creates a new AES Key Wrap based key manager using alg as the
+// algorithm and kek as the key encryption key. If alg does not describe an
+// AES Key Wrap algorithm or kek has the wrong size a non-nil error is returned.
+func AESKW(alg KeyManagementAlgorithm, kek []byte) (*AESKWKeyManager, error) {
+	switch alg {
+	case ALG_A128KW:
+		return A128KW(kek)
+	case ALG_A192KW:
+		return A192KW(kek)
+	case ALG_A256KW:
+		return A256KW(kek)
+	default:
+		return nil, fmt.Errorf("unsupported AES key wrap algorithm: %s", alg)
+	}
+}
+
+// A128KW creates a key manager implementing AES Key Wrap using the given
+// 128 bit key encryption key.
+func A128KW(kek []byte) (*AESKWKeyManager, error) {
+	return newAESKWKeyManager(ALG_A128KW, kek, 16)
+}
+
+// A192KW creates a key manager implementing AES Key Wrap using the given
+// 192 bit key encryption key.
+func A192KW(kek []byte) (*AESKWKeyManager, error) {
+	return newAESKWKeyManager(ALG_A192KW, kek, 24)
+}
+
+// A256KW creates a key manager implementing AES Key Wrap using the given
+// 256 bit key encryption key.
+func A256KW(kek []byte) (*AESKWKeyManager, error) {
+	return newAESKWKeyManager(ALG_A256KW, kek, 32)
+}
+
+func newAESKWKeyManager(alg KeyManagementAlgorithm, kek []byte, size int) (*AESKWKeyManager, error) {
+	if len(kek) != size {
+		return nil, fmt.Errorf("invalid key: %s requires a key of %d bytes, got %d", alg, size, len(kek))
+	}
+
+	return &AESKWKeyManager{
+		alg: alg,
+		kek: kek,
+	}, nil
+}
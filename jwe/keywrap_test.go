@@ -0,0 +1,139 @@
+package jwe
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestKeyWrap(t *testing.T) {
+	// Test vectors from RFC 3394 section 4
+	// (https://datatracker.ietf.org/doc/html/rfc3394#section-4)
+	tests := []struct {
+		name    string
+		kek     string
+		key     string
+		wrapped string
+	}{
+		{
+			name:    "128 bit key with 128 bit kek",
+			kek:     "000102030405060708090A0B0C0D0E0F",
+			key:     "00112233445566778899AABBCCDDEEFF",
+			wrapped: "1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5",
+		},
+		{
+			name:    "128 bit key with 192 bit kek",
+			kek:     "000102030405060708090A0B0C0D0E0F1011121314151617",
+			key:     "00112233445566778899AABBCCDDEEFF",
+			wrapped: "96778B25AE6CA435F92B5B97C050AED2468AB8A17AD84E5D",
+		},
+		{
+			name:    "128 bit key with 256 bit kek",
+			kek:     "000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F",
+			key:     "00112233445566778899AABBCCDDEEFF",
+			wrapped: "64E8C3F9CE0F5BA263E9777905818A2A93C8191E7D6E8AE7",
+		},
+		{
+			name:    "192 bit key with 192 bit kek",
+			kek:     "000102030405060708090A0B0C0D0E0F1011121314151617",
+			key:     "00112233445566778899AABBCCDDEEFF0001020304050607",
+			wrapped: "031D33264E15D33268F24EC260743EDCE1C6C7DDEE725A936BA814915C6762D2",
+		},
+		{
+			name:    "192 bit key with 256 bit kek",
+			kek:     "000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F",
+			key:     "00112233445566778899AABBCCDDEEFF0001020304050607",
+			wrapped: "A8F9BC1612C68B3FF6E6F4FBE30E71E4769C8B80A32CB8958CD5D17D6B254DA1",
+		},
+		{
+			name:    "256 bit key with 256 bit kek",
+			kek:     "000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F",
+			key:     "00112233445566778899AABBCCDDEEFF000102030405060708090A0B0C0D0E0F",
+			wrapped: "28C9F404C4B810F4CBCCB35CFB87F8263F5786E2D80ED326CBC7F0E71A99F43BFB988B9B7A02DD21",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kek := mustDecodeHex(t, test.kek)
+			key := mustDecodeHex(t, test.key)
+			want := mustDecodeHex(t, test.wrapped)
+
+			wrapped, err := keyWrap(kek, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(want, wrapped) {
+				t.Errorf("expected %X but got %X", want, wrapped)
+			}
+
+			unwrapped, err := keyUnwrap(kek, wrapped)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(key, unwrapped) {
+				t.Errorf("expected %X but got %X", key, unwrapped)
+			}
+		})
+	}
+}
+
+func TestKeyUnwrap_integrityCheck(t *testing.T) {
+	kek := mustDecodeHex(t, "000102030405060708090A0B0C0D0E0F")
+	wrapped := mustDecodeHex(t, "1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5")
+	wrapped[10] ^= 1
+
+	if _, err := keyUnwrap(kek, wrapped); err == nil {
+		t.Error("expected error but got nil")
+	}
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestAESKW(t *testing.T) {
+	tests := []struct {
+		alg KeyManagementAlgorithm
+		kek []byte
+	}{
+		{ALG_A128KW, []byte("0123456789abcdef")},
+		{ALG_A192KW, []byte("0123456789abcdef01234567")},
+		{ALG_A256KW, []byte("0123456789abcdef0123456789abcdef")},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.alg), func(t *testing.T) {
+			km, err := AESKW(test.alg, test.kek)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			j, err := Encrypt(km, ENC_A256GCM, []byte("hello, world"), Header{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			plaintext, err := j.Decrypt(km)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(plaintext) != "hello, world" {
+				t.Errorf("corrupted payload: %q", string(plaintext))
+			}
+		})
+	}
+
+	t.Run("invalid key size", func(t *testing.T) {
+		if _, err := A128KW([]byte("short")); err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+}
@@ -0,0 +1,227 @@
+//go:build go1.24
+
+package jwe
+
+import (
+	"crypto/mlkem"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/halimath/jose/internal/encoding"
+	"github.com/halimath/jose/jwk"
+)
+
+type mlkemEncapsulationKey interface {
+	Encapsulate() (sharedKey, ciphertext []byte)
+}
+
+type mlkemDecapsulationKey interface {
+	Decapsulate(ciphertext []byte) (sharedKey []byte, err error)
+}
+
+// mlkemKeyWrapSize returns the size of the key encryption key used by alg in
+// bytes or 0 if alg uses the derived key directly.
+func mlkemKeyWrapSize(alg KeyManagementAlgorithm) int {
+	switch alg {
+	case ALG_MLKEM768_A192KW:
+		return 24
+	case ALG_MLKEM1024_A256KW:
+		return 32
+	default:
+		return 0
+	}
+}
+
+// mlkemEncrypter implements the ML-KEM based key management algorithms on
+// the producing side using the recipient's encapsulation key.
+type mlkemEncrypter struct {
+	alg KeyManagementAlgorithm
+	ek  mlkemEncapsulationKey
+}
+
+func (m *mlkemEncrypter) Alg() KeyManagementAlgorithm {
+	return m.alg
+}
+
+func (m *mlkemEncrypter) EncryptKey(enc ContentEncryptionAlgorithm, header *Header) ([]byte, []byte, error) {
+	if mlkemKeyWrapSize(m.alg) > 0 {
+		cek, err := generateKey(enc)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		encryptedKey, err := m.WrapKey(cek, header)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return cek, encryptedKey, nil
+	}
+
+	if enc.KeySize() == 0 {
+		return nil, nil, fmt.Errorf("unsupported content encryption algorithm: %s", enc)
+	}
+
+	sharedKey, ciphertext := m.ek.Encapsulate()
+	header.EncapsulatedKey = encoding.Encode(ciphertext)
+
+	return concatKDF(sharedKey, []byte(enc), nil, nil, enc.KeySize()), []byte{}, nil
+}
+
+func (m *mlkemEncrypter) WrapKey(cek []byte, header *Header) ([]byte, error) {
+	size := mlkemKeyWrapSize(m.alg)
+	if size == 0 {
+		return nil, fmt.Errorf("%s uses direct key agreement and cannot wrap a content encryption key", m.alg)
+	}
+
+	sharedKey, ciphertext := m.ek.Encapsulate()
+	header.EncapsulatedKey = encoding.Encode(ciphertext)
+
+	return keyWrap(concatKDF(sharedKey, []byte(m.alg), nil, nil, size), cek)
+}
+
+// MLKEMEncrypter creates a KeyWrapper implementing the ML-KEM based key
+// management algorithm alg using the recipient's encapsulationKey given in
+// its encoded form. If alg denotes a direct key agreement algorithm
+// (ALG_MLKEM768 or ALG_MLKEM1024) the returned value cannot be used to encrypt
+// for multiple recipients.
+func MLKEMEncrypter(alg KeyManagementAlgorithm, encapsulationKey []byte) (KeyWrapper, error) {
+	var ek mlkemEncapsulationKey
+	var err error
+
+	switch alg {
+	case ALG_MLKEM768, ALG_MLKEM768_A192KW:
+		ek, err = mlkem.NewEncapsulationKey768(encapsulationKey)
+	case ALG_MLKEM1024, ALG_MLKEM1024_A256KW:
+		ek, err = mlkem.NewEncapsulationKey1024(encapsulationKey)
+	default:
+		return nil, fmt.Errorf("unsupported ML-KEM key management algorithm: %s", alg)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid ML-KEM encapsulation key: %v", err)
+	}
+
+	return &mlkemEncrypter{
+		alg: alg,
+		ek:  ek,
+	}, nil
+}
+
+// --
+
+// mlkemDecrypter implements the ML-KEM based key management algorithms on
+// the consuming side using the recipient's decapsulation key.
+type mlkemDecrypter struct {
+	alg KeyManagementAlgorithm
+	dk  mlkemDecapsulationKey
+}
+
+func (m *mlkemDecrypter) matchesRecipient(header Header) bool {
+	return header.Algorithm == m.alg
+}
+
+func (m *mlkemDecrypter) DecryptKey(header Header, encryptedKey []byte) ([]byte, error) {
+	if header.Algorithm != m.alg {
+		return nil, fmt.Errorf("key management algorithms do not match: %s vs. %s", m.alg, header.Algorithm)
+	}
+
+	ciphertext, err := encoding.Decode(header.EncapsulatedKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ek header: %v", err)
+	}
+
+	sharedKey, err := m.dk.Decapsulate(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	size := mlkemKeyWrapSize(m.alg)
+	if size == 0 {
+		if len(encryptedKey) != 0 {
+			return nil, errors.New("encrypted key must be empty for direct key agreement")
+		}
+
+		if header.Encryption.KeySize() == 0 {
+			return nil, fmt.Errorf("unsupported content encryption algorithm: %s", header.Encryption)
+		}
+
+		return concatKDF(sharedKey, []byte(header.Encryption), nil, nil, header.Encryption.KeySize()), nil
+	}
+
+	cek, err := keyUnwrap(concatKDF(sharedKey, []byte(m.alg), nil, nil, size), encryptedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cek) != header.Encryption.KeySize() {
+		return nil, fmt.Errorf("invalid content encryption key size for %s: %d", header.Encryption, len(cek))
+	}
+
+	return cek, nil
+}
+
+// MLKEMDecrypter creates a KeyDecrypter implementing the ML-KEM based key
+// management algorithm alg using the decapsulation key given as its 64 byte
+// seed.
+func MLKEMDecrypter(alg KeyManagementAlgorithm, seed []byte) (KeyDecrypter, error) {
+	var dk mlkemDecapsulationKey
+	var err error
+
+	switch alg {
+	case ALG_MLKEM768, ALG_MLKEM768_A192KW:
+		dk, err = mlkem.NewDecapsulationKey768(seed)
+	case ALG_MLKEM1024, ALG_MLKEM1024_A256KW:
+		dk, err = mlkem.NewDecapsulationKey1024(seed)
+	default:
+		return nil, fmt.Errorf("unsupported ML-KEM key management algorithm: %s", alg)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid ML-KEM decapsulation key: %v", err)
+	}
+
+	return &mlkemDecrypter{
+		alg: alg,
+		dk:  dk,
+	}, nil
+}
+
+func keyDecrypterFromAKPKey(key *jwk.AKPKey, alg KeyManagementAlgorithm) (KeyDecrypter, error) {
+	if len(key.Private) == 0 {
+		return nil, fmt.Errorf("key %q contains no private key", key.ID())
+	}
+
+	return MLKEMDecrypter(alg, key.Private)
+}
+
+// concatKDF implements the Concat KDF as defined in RFC 7518 section 4.6.2
+// (https://www.rfc-editor.org/rfc/rfc7518.html#section-4.6.2) using SHA-256.
+func concatKDF(z, algorithmID, partyUInfo, partyVInfo []byte, keyLen int) []byte {
+	var buf [4]byte
+
+	otherInfo := make([]byte, 0, 16+len(algorithmID)+len(partyUInfo)+len(partyVInfo))
+	for _, v := range [][]byte{algorithmID, partyUInfo, partyVInfo} {
+		binary.BigEndian.PutUint32(buf[:], uint32(len(v)))
+		otherInfo = append(otherInfo, buf[:]...)
+		otherInfo = append(otherInfo, v...)
+	}
+	binary.BigEndian.PutUint32(buf[:], uint32(keyLen*8))
+	otherInfo = append(otherInfo, buf[:]...)
+
+	h := sha256.New()
+	out := make([]byte, 0, keyLen+h.Size())
+
+	for counter := uint32(1); len(out) < keyLen; counter++ {
+		h.Reset()
+		binary.BigEndian.PutUint32(buf[:], counter)
+		h.Write(buf[:])
+		h.Write(z)
+		h.Write(otherInfo)
+		out = h.Sum(out)
+	}
+
+	return out[:keyLen]
+}
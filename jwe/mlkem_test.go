@@ -0,0 +1,165 @@
+//go:build go1.24
+
+package jwe
+
+import (
+	"crypto/mlkem"
+	"errors"
+	"testing"
+
+	"github.com/halimath/jose/internal/encoding"
+	"github.com/halimath/jose/jwk"
+)
+
+func TestConcatKDF(t *testing.T) {
+	// Test vector from RFC 7518 appendix C
+	// (https://www.rfc-editor.org/rfc/rfc7518.html#appendix-C)
+	z := []byte{158, 86, 217, 29, 129, 113, 53, 211, 114, 131, 66, 131, 191, 132,
+		38, 156, 251, 49, 110, 163, 218, 128, 106, 72, 246, 218, 167, 121,
+		140, 254, 144, 196}
+
+	got := encoding.Encode(concatKDF(z, []byte("A128GCM"), []byte("Alice"), []byte("Bob"), 16))
+	if got != "VqqN6vgjbSBcIijNcacQGg" {
+		t.Errorf("unexpected derived key: %s", got)
+	}
+}
+
+func TestMLKEM(t *testing.T) {
+	dk768, err := mlkem.GenerateKey768()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dk1024, err := mlkem.GenerateKey1024()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		alg  KeyManagementAlgorithm
+		ek   []byte
+		seed []byte
+	}{
+		{ALG_MLKEM768, dk768.EncapsulationKey().Bytes(), dk768.Bytes()},
+		{ALG_MLKEM768_A192KW, dk768.EncapsulationKey().Bytes(), dk768.Bytes()},
+		{ALG_MLKEM1024, dk1024.EncapsulationKey().Bytes(), dk1024.Bytes()},
+		{ALG_MLKEM1024_A256KW, dk1024.EncapsulationKey().Bytes(), dk1024.Bytes()},
+	}
+
+	const payload = "hello, world"
+
+	for _, test := range tests {
+		t.Run(string(test.alg), func(t *testing.T) {
+			encrypter, err := MLKEMEncrypter(test.alg, test.ek)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			decrypter, err := MLKEMDecrypter(test.alg, test.seed)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			j, err := Encrypt(encrypter, ENC_A256GCM, []byte(payload), Header{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if j.Header().EncapsulatedKey == "" {
+				t.Error("missing ek header")
+			}
+
+			parsed, err := ParseCompact(j.Compact())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			plaintext, err := parsed.Decrypt(decrypter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(plaintext) != payload {
+				t.Errorf("corrupted payload: %q != %q", payload, string(plaintext))
+			}
+		})
+	}
+
+	t.Run("wrong key", func(t *testing.T) {
+		encrypter, err := MLKEMEncrypter(ALG_MLKEM768_A192KW, dk768.EncapsulationKey().Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		other, err := mlkem.GenerateKey768()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decrypter, err := MLKEMDecrypter(ALG_MLKEM768_A192KW, other.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		j, err := Encrypt(encrypter, ENC_A256GCM, []byte(payload), Header{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := j.Decrypt(decrypter); !errors.Is(err, ErrDecryptionFailed) {
+			t.Errorf("expected ErrDecryptionFailed but got %v", err)
+		}
+	})
+
+	t.Run("multiple recipients", func(t *testing.T) {
+		kw, err := MLKEMEncrypter(ALG_MLKEM1024_A256KW, dk1024.EncapsulationKey().Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		aesKW, err := A128KW([]byte("0123456789abcdef"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		j, err := EncryptJSON(ENC_A128CBC_HS256, []byte(payload), Header{}, Header{}, nil,
+			Recipient{KeyWrapper: aesKW, Header: Header{KeyID: "aes"}},
+			Recipient{KeyWrapper: kw, Header: Header{KeyID: "pq"}},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		set := jwk.Set{
+			&jwk.AKPKey{
+				KeyDescription: jwk.KeyDescription{
+					KeyID:        "pq",
+					KeyUse:       jwk.UseEncryption,
+					KeyAlgorithm: string(ALG_MLKEM1024_A256KW),
+				},
+				Public:  dk1024.EncapsulationKey().Bytes(),
+				Private: dk1024.Bytes(),
+			},
+		}
+
+		plaintext, err := j.DecryptWithKeySet(set)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(plaintext) != payload {
+			t.Errorf("corrupted payload: %q != %q", payload, string(plaintext))
+		}
+	})
+
+	t.Run("direct with multiple recipients", func(t *testing.T) {
+		direct, err := MLKEMEncrypter(ALG_MLKEM768, dk768.EncapsulationKey().Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := EncryptJSON(ENC_A128GCM, []byte(payload), Header{}, Header{}, nil, Recipient{KeyWrapper: direct}); err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+}
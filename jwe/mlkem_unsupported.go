@@ -0,0 +1,29 @@
+//go:build !go1.24
+
+package jwe
+
+import (
+	"errors"
+
+	"github.com/halimath/jose/jwk"
+)
+
+var errMLKEMUnsupported = errors.New("ML-KEM based key management requires go1.24 or later")
+
+// MLKEMEncrypter creates a KeyWrapper implementing the ML-KEM based key
+// management algorithm alg. It always returns an error as ML-KEM requires
+// go1.24 or later.
+func MLKEMEncrypter(alg KeyManagementAlgorithm, encapsulationKey []byte) (KeyWrapper, error) {
+	return nil, errMLKEMUnsupported
+}
+
+// MLKEMDecrypter creates a KeyDecrypter implementing the ML-KEM based key
+// management algorithm alg. It always returns an error as ML-KEM requires
+// go1.24 or later.
+func MLKEMDecrypter(alg KeyManagementAlgorithm, seed []byte) (KeyDecrypter, error) {
+	return nil, errMLKEMUnsupported
+}
+
+func keyDecrypterFromAKPKey(key *jwk.AKPKey, alg KeyManagementAlgorithm) (KeyDecrypter, error) {
+	return nil, errMLKEMUnsupported
+}
@@ -0,0 +1,18 @@
+//go:build !go1.24
+
+package jwe
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMLKEM_unsupported(t *testing.T) {
+	if _, err := MLKEMEncrypter(ALG_MLKEM768, nil); !errors.Is(err, errMLKEMUnsupported) {
+		t.Errorf("expected errMLKEMUnsupported but got %v", err)
+	}
+
+	if _, err := MLKEMDecrypter(ALG_MLKEM768, nil); !errors.Is(err, errMLKEMUnsupported) {
+		t.Errorf("expected errMLKEMUnsupported but got %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package jwe
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"hash"
+
+	"github.com/halimath/jose/internal/encoding"
+)
+
+const (
+	// DefaultPBES2Count is the default PBKDF2 iteration count used when
+	// encrypting with a PBES2 algorithm.
+	DefaultPBES2Count = 100000
+
+	// DefaultPBES2MaxCount is the default maximum value of the "p2c" header
+	// parameter accepted when decrypting with a PBES2 algorithm.
+	DefaultPBES2MaxCount = 200000
+
+	// DefaultPBES2SaltSize is the default size in bytes of the salt input
+	// generated when encrypting with a PBES2 algorithm.
+	DefaultPBES2SaltSize = 16
+
+	// minPBES2SaltSize is the minimum salt input size as required by RFC 7518 section 4.8.1.1
+	// (https://www.rfc-editor.org/rfc/rfc7518.html#section-4.8.1.1)
+	minPBES2SaltSize = 8
+)
+
+// PBES2KeyManager implements the PBES2 key management algorithms with
+// HMAC SHA-2 based PBKDF2 key derivation and AES key wrapping as defined
+// in RFC 7518 section 4.8
+// (https://www.rfc-editor.org/rfc/rfc7518.html#section-4.8)
+type PBES2KeyManager struct {
+	alg      KeyManagementAlgorithm
+	password []byte
+	h        func() hash.Hash
+	keySize  int
+
+	// Count is the PBKDF2 iteration count used when encrypting. If Count is
+	// less than or equal to zero DefaultPBES2Count is used.
+	Count int
+
+	// MaxCount is the maximum iteration count accepted from the "p2c" header
+	// when decrypting. It protects against CPU exhaustion caused by hostile
+	// tokens. If MaxCount is less than or equal to zero DefaultPBES2MaxCount
+	// is used.
+	MaxCount int
+
+	// SaltSize is the size of the random salt input in bytes generated when
+	// encrypting. If SaltSize is less than or equal to zero
+	// DefaultPBES2SaltSize is used.
+	SaltSize int
+}
+
+func (p *PBES2KeyManager) Alg() KeyManagementAlgorithm {
+	return p.alg
+}
+
+func (p *PBES2KeyManager) EncryptKey(enc ContentEncryptionAlgorithm, header *Header) ([]byte, []byte, error) {
+	cek, err := generateKey(enc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encryptedKey, err := p.WrapKey(cek, header)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cek, encryptedKey, nil
+}
+
+func (p *PBES2KeyManager) WrapKey(cek []byte, header *Header) ([]byte, error) {
+	count := p.Count
+	if count <= 0 {
+		count = DefaultPBES2Count
+	}
+
+	saltSize := p.SaltSize
+	if saltSize <= 0 {
+		saltSize = DefaultPBES2SaltSize
+	}
+	if saltSize < minPBES2SaltSize {
+		return nil, fmt.Errorf("PBES2 salt input too short: %d", saltSize)
+	}
+
+	saltInput := make([]byte, saltSize)
+	if _, err := rand.Read(saltInput); err != nil {
+		return nil, err
+	}
+
+	encryptedKey, err := keyWrap(p.deriveKey(saltInput, count), cek)
+	if err != nil {
+		return nil, err
+	}
+
+	header.PBES2SaltInput = encoding.Encode(saltInput)
+	header.PBES2Count = count
+
+	return encryptedKey, nil
+}
+
+func (p *PBES2KeyManager) DecryptKey(header Header, encryptedKey []byte) ([]byte, error) {
+	if header.Algorithm != p.alg {
+		return nil, fmt.Errorf("key management algorithms do not match: %s vs. %s", p.alg, header.Algorithm)
+	}
+
+	maxCount := p.MaxCount
+	if maxCount <= 0 {
+		maxCount = DefaultPBES2MaxCount
+	}
+
+	if header.PBES2Count <= 0 {
+		return nil, fmt.Errorf("missing or invalid p2c header: %d", header.PBES2Count)
+	}
+	if header.PBES2Count > maxCount {
+		return nil, fmt.Errorf("p2c header exceeds maximum of %d: %d", maxCount, header.PBES2Count)
+	}
+
+	saltInput, err := encoding.Decode(header.PBES2SaltInput)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p2s header: %v", err)
+	}
+	if len(saltInput) < minPBES2SaltSize {
+		return nil, fmt.Errorf("p2s header too short: %d", len(saltInput))
+	}
+
+	cek, err := keyUnwrap(p.deriveKey(saltInput, header.PBES2Count), encryptedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cek) != header.Encryption.KeySize() {
+		return nil, fmt.Errorf("invalid content encryption key size for %s: %d", header.Encryption, len(cek))
+	}
+
+	return cek, nil
+}
+
+// deriveKey derives the key encryption key from p's password using the salt
+// value (UTF8(alg) || 0x00 || salt input) as defined in RFC 7518 section 4.8.1.1
+func (p *PBES2KeyManager) deriveKey(saltInput []byte, count int) []byte {
+	salt := make([]byte, 0, len(p.alg)+1+len(saltInput))
+	salt = append(salt, p.alg...)
+	salt = append(salt, 0)
+	salt = append(salt, saltInput...)
+
+	return pbkdf2(p.h, p.password, salt, count, p.keySize)
+}
+
+// PBES2 creates a new PBES2 based key manager using alg as the
+// algorithm and password as the password. If alg does not describe a
+// PBES2 algorithm a non-nil error is returned.
+func PBES2(alg KeyManagementAlgorithm, password []byte) (*PBES2KeyManager, error) {
+	switch alg {
+	case ALG_PBES2_HS256_A128KW:
+		return PBES2HS256A128KW(password), nil
+	case ALG_PBES2_HS384_A192KW:
+		return PBES2HS384A192KW(password), nil
+	case ALG_PBES2_HS512_A256KW:
+		return PBES2HS512A256KW(password), nil
+	default:
+		return nil, fmt.Errorf("unsupported PBES2 key management algorithm: %s", alg)
+	}
+}
+
+// PBES2HS256A128KW creates a key manager implementing PBES2 with HMAC SHA-256
+// and A128KW wrapping.
+func PBES2HS256A128KW(password []byte) *PBES2KeyManager {
+	return &PBES2KeyManager{
+		alg:      ALG_PBES2_HS256_A128KW,
+		password: password,
+		h:        sha256.New,
+		keySize:  16,
+	}
+}
+
+// PBES2HS384A192KW creates a key manager implementing PBES2 with HMAC SHA-384
+// and A192KW wrapping.
+func PBES2HS384A192KW(password []byte) *PBES2KeyManager {
+	return &PBES2KeyManager{
+		alg:      ALG_PBES2_HS384_A192KW,
+		password: password,
+		h:        sha512.New384,
+		keySize:  24,
+	}
+}
+
+// PBES2HS512A256KW creates a key manager implementing PBES2 with HMAC SHA-512
+// and A256KW wrapping.
+func PBES2HS512A256KW(password []byte) *PBES2KeyManager {
+	return &PBES2KeyManager{
+		alg:      ALG_PBES2_HS512_A256KW,
+		password: password,
+		h:        sha512.New,
+		keySize:  32,
+	}
+}
+
+// pbkdf2 implements the PBKDF2 key derivation function as defined in
+// RFC 8018 section 5.2 (https://datatracker.ietf.org/doc/html/rfc8018#section-5.2)
+// using HMAC with h as the pseudorandom function.
+func pbkdf2(h func() hash.Hash, password, salt []byte, count, keyLen int) []byte {
+	prf := hmac.New(h, password)
+	hashLen := prf.Size()
+	numBlocks := (keyLen + hashLen - 1) / hashLen
+
+	var buf [4]byte
+	dk := make([]byte, 0, numBlocks*hashLen)
+	u := make([]byte, hashLen)
+
+	for block := 1; block <= numBlocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf[:], uint32(block))
+		prf.Write(buf[:])
+		dk = prf.Sum(dk)
+
+		t := dk[len(dk)-hashLen:]
+		copy(u, t)
+
+		for n := 2; n <= count; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for i := range u {
+				t[i] ^= u[i]
+			}
+		}
+	}
+
+	return dk[:keyLen]
+}
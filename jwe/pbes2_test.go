@@ -0,0 +1,164 @@
+package jwe
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/halimath/jose/internal/encoding"
+)
+
+func TestPBKDF2(t *testing.T) {
+	tests := []struct {
+		password string
+		salt     string
+		count    int
+		want     string
+	}{
+		// PBKDF2-HMAC-SHA256 with password "password" and salt "salt"
+		{"password", "salt", 1, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{"password", "salt", 2, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+		{"password", "salt", 4096, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+		// RFC 7914 section 11 (https://datatracker.ietf.org/doc/html/rfc7914#section-11)
+		{"passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+	}
+
+	for _, test := range tests {
+		got := hex.EncodeToString(pbkdf2(sha256.New, []byte(test.password), []byte(test.salt), test.count, len(test.want)/2))
+		if got != test.want {
+			t.Errorf("%s/%s/%d: expected %s but got %s", test.password, test.salt, test.count, test.want, got)
+		}
+	}
+}
+
+func TestPBES2_rfc7517(t *testing.T) {
+	// Example from RFC 7517 appendix C
+	// (https://datatracker.ietf.org/doc/html/rfc7517#appendix-C)
+	km := PBES2HS256A128KW([]byte("Thus from my lips, by yours, my sin is purged."))
+
+	header := Header{
+		Algorithm:      ALG_PBES2_HS256_A128KW,
+		Encryption:     ENC_A128CBC_HS256,
+		PBES2SaltInput: "2WCTcJZ1Rvd_CJuJripQ1w",
+		PBES2Count:     4096,
+	}
+
+	cek := []byte{
+		111, 27, 25, 52, 66, 29, 20, 78, 92, 176, 56, 240, 65, 208, 82, 112,
+		161, 131, 36, 55, 202, 236, 185, 172, 129, 23, 153, 194, 195, 48, 253, 182,
+	}
+	kek := []byte{110, 171, 169, 92, 129, 92, 109, 117, 233, 242, 116, 233, 170, 14, 24, 75}
+	encryptedKey := []byte{
+		78, 186, 151, 59, 11, 141, 81, 240, 213, 245, 83, 211, 53, 188, 134, 188,
+		66, 125, 36, 200, 222, 124, 5, 103, 249, 52, 117, 184, 140, 81, 246, 158,
+		161, 177, 20, 33, 245, 57, 59, 4,
+	}
+
+	saltInput, err := encoding.Decode(header.PBES2SaltInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := km.deriveKey(saltInput, header.PBES2Count); !bytes.Equal(got, kek) {
+		t.Errorf("expected derived key %v but got %v", kek, got)
+	}
+
+	wrapped, err := keyWrap(kek, cek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(wrapped, encryptedKey) {
+		t.Errorf("expected encrypted key %v but got %v", encryptedKey, wrapped)
+	}
+
+	got, err := km.DecryptKey(header, encryptedKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, cek) {
+		t.Errorf("expected content encryption key %v but got %v", cek, got)
+	}
+}
+
+func TestPBES2(t *testing.T) {
+	algs := []KeyManagementAlgorithm{
+		ALG_PBES2_HS256_A128KW,
+		ALG_PBES2_HS384_A192KW,
+		ALG_PBES2_HS512_A256KW,
+	}
+
+	const payload = "hello, world"
+
+	for _, alg := range algs {
+		t.Run(string(alg), func(t *testing.T) {
+			km, err := PBES2(alg, []byte("Thus from my lips, by yours, my sin is purged."))
+			if err != nil {
+				t.Fatal(err)
+			}
+			km.Count = 1000
+
+			j, err := Encrypt(km, ENC_A128CBC_HS256, []byte(payload), Header{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			h := j.Header()
+			if h.Algorithm != alg {
+				t.Errorf("unexpected alg: %s", h.Algorithm)
+			}
+			if h.PBES2Count != 1000 {
+				t.Errorf("unexpected p2c: %d", h.PBES2Count)
+			}
+			if len(h.PBES2SaltInput) == 0 {
+				t.Error("missing p2s")
+			}
+
+			parsed, err := ParseCompact(j.Compact())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			plaintext, err := parsed.Decrypt(km)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(plaintext) != payload {
+				t.Errorf("corrupted payload: %q != %q", payload, string(plaintext))
+			}
+		})
+	}
+}
+
+func TestPBES2_wrongPassword(t *testing.T) {
+	km := PBES2HS256A128KW([]byte("secret"))
+	km.Count = 1000
+
+	j, err := Encrypt(km, ENC_A128GCM, []byte("hello, world"), Header{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := j.Decrypt(PBES2HS256A128KW([]byte("another-secret"))); !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("expected ErrDecryptionFailed but got %v", err)
+	}
+}
+
+func TestPBES2_maxCount(t *testing.T) {
+	km := PBES2HS256A128KW([]byte("secret"))
+	km.Count = 2000
+
+	j, err := Encrypt(km, ENC_A128GCM, []byte("hello, world"), Header{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dec := PBES2HS256A128KW([]byte("secret"))
+	dec.MaxCount = 1000
+
+	if _, err := j.Decrypt(dec); !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("expected ErrDecryptionFailed but got %v", err)
+	}
+}
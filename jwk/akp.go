@@ -0,0 +1,75 @@
+package jwk
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/halimath/jose/internal/encoding"
+)
+
+// AKPKey implements a key of "kty": "AKP" (Algorithm Key Pair) as proposed
+// by the IETF JOSE drafts for post-quantum algorithms such as ML-KEM. The
+// key holds the raw public key bytes and optionally the raw private key
+// bytes. Their interpretation is defined by the algorithm named in the key's
+// "alg" parameter.
+type AKPKey struct {
+	KeyDescription
+	Public  []byte
+	Private []byte
+}
+
+func (a *AKPKey) Type() KeyType {
+	return KeyTypeAKP
+}
+
+type akpKeyJSONWrapper struct {
+	KeyDescription
+	Type    KeyType `json:"kty"`
+	Public  string  `json:"pub"`
+	Private string  `json:"priv,omitempty"`
+}
+
+func (a *AKPKey) MarshalJSON() ([]byte, error) {
+	w := akpKeyJSONWrapper{
+		KeyDescription: a.KeyDescription,
+		Type:           a.Type(),
+		Public:         encoding.Encode(a.Public),
+	}
+
+	if len(a.Private) > 0 {
+		w.Private = encoding.Encode(a.Private)
+	}
+
+	return json.Marshal(w)
+}
+
+func (a *AKPKey) UnmarshalJSON(data []byte) error {
+	var w akpKeyJSONWrapper
+
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+
+	if w.Type != KeyTypeAKP {
+		return fmt.Errorf("invalid key type: %s", w.Type)
+	}
+
+	pub, err := encoding.Decode(w.Public)
+	if err != nil {
+		return fmt.Errorf("invalid pub value: %v", err)
+	}
+
+	var priv []byte
+	if w.Private != "" {
+		priv, err = encoding.Decode(w.Private)
+		if err != nil {
+			return fmt.Errorf("invalid priv value: %v", err)
+		}
+	}
+
+	a.KeyDescription = w.KeyDescription
+	a.Public = pub
+	a.Private = priv
+
+	return nil
+}
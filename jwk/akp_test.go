@@ -0,0 +1,44 @@
+package jwk
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestAKPKey_JSONSerialization(t *testing.T) {
+	const jsonData = `{"use":"enc","alg":"MLKEM768+A192KW","kid":"1","kty":"AKP","pub":"AQID","priv":"BAU"}`
+
+	key := AKPKey{
+		KeyDescription: KeyDescription{
+			KeyUse:       UseEncryption,
+			KeyAlgorithm: "MLKEM768+A192KW",
+			KeyID:        "1",
+		},
+		Public:  []byte{1, 2, 3},
+		Private: []byte{4, 5},
+	}
+
+	t.Run("marshal", func(t *testing.T) {
+		got, err := json.Marshal(&key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(got) != jsonData {
+			t.Errorf("expected\n%s but got\n%s", jsonData, string(got))
+		}
+	})
+
+	t.Run("unmarshal", func(t *testing.T) {
+		got, err := UnmarshalKey([]byte(jsonData))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := deep.Equal(&key, got); diff != nil {
+			t.Error(diff)
+		}
+	})
+}
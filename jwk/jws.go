@@ -0,0 +1,86 @@
+package jwk
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/halimath/jose/jws"
+)
+
+// ErrUnsuitableKey is returned (maybe wrapped) when a key's parameters do not
+// permit the requested operation.
+var ErrUnsuitableKey = errors.New("unsuitable key")
+
+// Verifier creates a jws.Verifier for alg using the key material from k. If
+// alg is empty, k's "alg" parameter is used. A non-nil error wrapping
+// ErrUnsuitableKey is returned if k's "use" is not "sig", k's "key_ops" do
+// not contain "verify" or k's "alg" denotes a different algorithm.
+func Verifier(k Key, alg jws.SignatureAlgorithm) (jws.Verifier, error) {
+	alg, err := checkSignatureKey(k, alg, KeyOpsVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := k.(type) {
+	case *SymmetricKey:
+		return jws.HSSignerVerifier(alg, key.Bytes)
+	case *RSAPublicKey:
+		return jws.RSVerifier(alg, key.PublicKey)
+	case *ECDSAPublicKey:
+		return jws.ESVerifier(alg, key.PublicKey)
+	default:
+		return nil, fmt.Errorf("%w: unsupported key type for verification: %s", ErrUnsuitableKey, k.Type())
+	}
+}
+
+// Signer creates a jws.Signer for alg using the key material from k. If alg
+// is empty, k's "alg" parameter is used. A non-nil error wrapping
+// ErrUnsuitableKey is returned if k's "use" is not "sig", k's "key_ops" do
+// not contain "sign", k's "alg" denotes a different algorithm or k contains
+// no private key material.
+func Signer(k Key, alg jws.SignatureAlgorithm) (jws.Signer, error) {
+	alg, err := checkSignatureKey(k, alg, KeyOpsSign)
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := k.(type) {
+	case *SymmetricKey:
+		return jws.HSSignerVerifier(alg, key.Bytes)
+	default:
+		return nil, fmt.Errorf("%w: key type %s contains no private key", ErrUnsuitableKey, k.Type())
+	}
+}
+
+// checkSignatureKey checks that k may be used to perform op using alg and
+// returns the algorithm to use.
+func checkSignatureKey(k Key, alg jws.SignatureAlgorithm, op KeyOp) (jws.SignatureAlgorithm, error) {
+	if k.Use() != "" && k.Use() != UseSignature {
+		return "", fmt.Errorf("%w: key %q has use %q", ErrUnsuitableKey, k.ID(), k.Use())
+	}
+
+	if ops := k.Operations(); len(ops) > 0 && !containsOp(ops, op) {
+		return "", fmt.Errorf("%w: key %q does not permit %s", ErrUnsuitableKey, k.ID(), op)
+	}
+
+	if alg == "" {
+		alg = jws.SignatureAlgorithm(k.Algorithm())
+	} else if k.Algorithm() != "" && k.Algorithm() != string(alg) {
+		return "", fmt.Errorf("%w: key %q is intended for %s, not %s", ErrUnsuitableKey, k.ID(), k.Algorithm(), alg)
+	}
+
+	if alg == "" || alg == jws.ALG_NONE {
+		return "", fmt.Errorf("%w: no signature algorithm for key %q", ErrUnsuitableKey, k.ID())
+	}
+
+	return alg, nil
+}
+
+func containsOp(ops []KeyOp, op KeyOp) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
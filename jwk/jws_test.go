@@ -0,0 +1,113 @@
+package jwk
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/halimath/jose/jws"
+)
+
+func TestVerifier(t *testing.T) {
+	secret := []byte("a secret with at least 32 bytes!")
+
+	signer, err := jws.HSSignerVerifier(jws.ALG_HS256, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j, err := jws.Sign(signer, []byte("payload"), jws.Header{Algorithm: jws.ALG_HS256})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("symmetric", func(t *testing.T) {
+		v, err := Verifier(&SymmetricKey{Bytes: secret}, jws.ALG_HS256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := j.VerifySignature(v); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("alg_from_key", func(t *testing.T) {
+		v, err := Verifier(&SymmetricKey{KeyDescription: KeyDescription{KeyAlgorithm: string(jws.ALG_HS256)}, Bytes: secret}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := j.VerifySignature(v); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("ecdsa", func(t *testing.T) {
+		pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Verifier(&ECDSAPublicKey{PublicKey: &pk.PublicKey}, jws.ALG_ES256); err != nil {
+			t.Error(err)
+		}
+	})
+
+	tests := map[string]Key{
+		"use_enc":            &SymmetricKey{KeyDescription: KeyDescription{KeyUse: UseEncryption}, Bytes: secret},
+		"ops_without_verify": &SymmetricKey{KeyDescription: KeyDescription{KeyOperations: []KeyOp{KeyOpsSign}}, Bytes: secret},
+		"alg_mismatch":       &SymmetricKey{KeyDescription: KeyDescription{KeyAlgorithm: string(jws.ALG_HS512)}, Bytes: secret},
+		"no_alg":             &SymmetricKey{Bytes: secret},
+	}
+
+	for name, key := range tests {
+		t.Run(name, func(t *testing.T) {
+			alg := jws.ALG_HS256
+			if name == "no_alg" {
+				alg = ""
+			}
+			if _, err := Verifier(key, alg); !errors.Is(err, ErrUnsuitableKey) {
+				t.Errorf("expected ErrUnsuitableKey but got %v", err)
+			}
+		})
+	}
+}
+
+func TestSigner(t *testing.T) {
+	secret := []byte("a secret with at least 32 bytes!")
+
+	t.Run("symmetric", func(t *testing.T) {
+		key := &SymmetricKey{KeyDescription: KeyDescription{KeyOperations: []KeyOp{KeyOpsSign, KeyOpsVerify}}, Bytes: secret}
+		s, err := Signer(key, jws.ALG_HS256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		j, err := jws.Sign(s, []byte("payload"), jws.Header{Algorithm: jws.ALG_HS256})
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := Verifier(key, jws.ALG_HS256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := j.VerifySignature(v); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("ops_without_sign", func(t *testing.T) {
+		key := &SymmetricKey{KeyDescription: KeyDescription{KeyOperations: []KeyOp{KeyOpsVerify}}, Bytes: secret}
+		if _, err := Signer(key, jws.ALG_HS256); !errors.Is(err, ErrUnsuitableKey) {
+			t.Errorf("expected ErrUnsuitableKey but got %v", err)
+		}
+	})
+
+	t.Run("public_key", func(t *testing.T) {
+		pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Signer(&ECDSAPublicKey{PublicKey: &pk.PublicKey}, jws.ALG_ES256); !errors.Is(err, ErrUnsuitableKey) {
+			t.Errorf("expected ErrUnsuitableKey but got %v", err)
+		}
+	})
+}
@@ -2,9 +2,14 @@ package jwk
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 )
 
+// ErrUnsupportedKeyType is returned (maybe wrapped) from UnmarshalKey if a
+// key's "kty" is not supported.
+var ErrUnsupportedKeyType = errors.New("unsupported kty")
+
 // KeyType defines the types of keys as specified in RFC 7518 section 6.1
 // (https://www.rfc-editor.org/rfc/rfc7518.html#section-6.1)
 type KeyType string
@@ -21,6 +26,9 @@ const (
 
 	// Key Type Octet Stream
 	KeyTypeOct KeyType = "oct"
+
+	// Key Type Algorithm Key Pair as proposed for post-quantum algorithms
+	KeyTypeAKP KeyType = "AKP"
 )
 
 // --
@@ -152,8 +160,16 @@ func UnmarshalKey(data []byte) (Key, error) {
 
 		return &k, nil
 
+	case KeyTypeAKP:
+		var k AKPKey
+		if err := json.Unmarshal(data, &k); err != nil {
+			return nil, err
+		}
+
+		return &k, nil
+
 	default:
-		return nil, fmt.Errorf("unsupported kty: %s", kw.Type)
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedKeyType, kw.Type)
 	}
 }
 
@@ -163,7 +179,7 @@ func UnmarshalKey(data []byte) (Key, error) {
 // be set.
 type KeyDescription struct {
 	KeyUse        KeyUse  `json:"use,omitempty"`
-	KeyOperations []KeyOp `json:"ops,omitempty"`
+	KeyOperations []KeyOp `json:"key_ops,omitempty"`
 	KeyAlgorithm  string  `json:"alg,omitempty"`
 	KeyID         string  `json:"kid,omitempty"`
 }
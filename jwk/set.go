@@ -2,6 +2,7 @@ package jwk
 
 import (
 	"encoding/json"
+	"errors"
 )
 
 // KeyFilter defines a function type to use to filter Keys in a Set.
@@ -38,6 +39,74 @@ func (s Set) First(f KeyFilter) Key {
 	return nil
 }
 
+// Public returns a Set containing only the public parts of the keys in s,
+// suitable for publication. Symmetric keys and keys of unknown types are
+// omitted. Private members of asymmetric keys are stripped. Key operations
+// requiring the private key are replaced with their public counterparts,
+// i.e. "sign" with "verify", "decrypt" with "encrypt" and "unwrapKey" with
+// "wrapKey". Keys whose operations have no public counterpart are omitted.
+func (s Set) Public() Set {
+	result := make(Set, 0, len(s))
+
+	for _, k := range s {
+		var desc KeyDescription
+		switch key := k.(type) {
+		case *RSAPublicKey:
+			desc = key.KeyDescription
+		case *ECDSAPublicKey:
+			desc = key.KeyDescription
+		case *AKPKey:
+			desc = key.KeyDescription
+		default:
+			continue
+		}
+
+		if len(desc.KeyOperations) > 0 {
+			desc.KeyOperations = publicKeyOps(desc.KeyOperations)
+			if len(desc.KeyOperations) == 0 {
+				continue
+			}
+		}
+
+		switch key := k.(type) {
+		case *RSAPublicKey:
+			result = append(result, &RSAPublicKey{KeyDescription: desc, PublicKey: key.PublicKey})
+		case *ECDSAPublicKey:
+			result = append(result, &ECDSAPublicKey{KeyDescription: desc, PublicKey: key.PublicKey})
+		case *AKPKey:
+			result = append(result, &AKPKey{KeyDescription: desc, Public: key.Public})
+		}
+	}
+
+	return result
+}
+
+// publicKeyOps maps ops to the operations that can be performed using the
+// public key only.
+func publicKeyOps(ops []KeyOp) []KeyOp {
+	var result []KeyOp
+
+	for _, op := range ops {
+		switch op {
+		case KeyOpsSign:
+			op = KeyOpsVerify
+		case KeyOpsDecrypt:
+			op = KeyOpsEncrypt
+		case KeyOpsUnwrapKey:
+			op = KeyOpsKeyWrap
+		case KeyOpsVerify, KeyOpsEncrypt, KeyOpsKeyWrap:
+		default:
+			continue
+		}
+
+		if !containsOp(result, op) {
+			result = append(result, op)
+		}
+	}
+
+	return result
+}
+
 const (
 	ParamKey = "keys"
 )
@@ -53,24 +122,46 @@ func (s Set) MarshalJSON() ([]byte, error) {
 }
 
 func (s *Set) UnmarshalJSON(data []byte) error {
+	set, err := UnmarshalSet(data, UnmarshalSetOptions{})
+	if err != nil {
+		return err
+	}
+
+	*s = set
+	return nil
+}
+
+// UnmarshalSetOptions defines the options for UnmarshalSet.
+type UnmarshalSetOptions struct {
+	// SkipUnsupportedKeys makes UnmarshalSet ignore keys with an unsupported
+	// "kty" instead of failing as recommended by RFC 7517 section 5
+	// (https://datatracker.ietf.org/doc/html/rfc7517#section-5).
+	SkipUnsupportedKeys bool
+}
+
+// UnmarshalSet unmarshals the JWK Set contained in data using opts.
+func UnmarshalSet(data []byte, opts UnmarshalSetOptions) (Set, error) {
 	type setWrapper struct {
 		Keys []json.RawMessage `json:"keys"`
 	}
 
 	var w setWrapper
 	if err := json.Unmarshal(data, &w); err != nil {
-		return err
+		return nil, err
 	}
 
-	*s = make(Set, len(w.Keys))
-	var err error
+	set := make(Set, 0, len(w.Keys))
 
-	for i, rm := range w.Keys {
-		(*s)[i], err = UnmarshalKey(rm)
+	for _, rm := range w.Keys {
+		k, err := UnmarshalKey(rm)
+		if opts.SkipUnsupportedKeys && errors.Is(err, ErrUnsupportedKeyType) {
+			continue
+		}
 		if err != nil {
-			return err
+			return nil, err
 		}
+		set = append(set, k)
 	}
 
-	return nil
+	return set, nil
 }
@@ -5,6 +5,7 @@ import (
 	"crypto/elliptic"
 	"crypto/rsa"
 	"encoding/json"
+	"errors"
 	"math/big"
 	"testing"
 
@@ -63,3 +64,88 @@ func TestSet_JSONSerialization(t *testing.T) {
 		}
 	})
 }
+
+func TestSet_Public(t *testing.T) {
+	ec := &ECDSAPublicKey{
+		PublicKey: &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     big.NewInt(1),
+			Y:     big.NewInt(2),
+		},
+	}
+
+	set := Set{
+		ec,
+		&SymmetricKey{Bytes: []byte("s3cr3t")},
+		&AKPKey{
+			KeyDescription: KeyDescription{KeyID: "akp"},
+			Public:         []byte("public"),
+			Private:        []byte("private"),
+		},
+	}
+
+	want := Set{
+		ec,
+		&AKPKey{
+			KeyDescription: KeyDescription{KeyID: "akp"},
+			Public:         []byte("public"),
+		},
+	}
+
+	if diff := deep.Equal(want, set.Public()); diff != nil {
+		t.Error(diff)
+	}
+
+	if len(set[2].(*AKPKey).Private) == 0 {
+		t.Error("expected original key to be unmodified")
+	}
+}
+
+func TestSet_Public_keyOps(t *testing.T) {
+	pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: big.NewInt(1), Y: big.NewInt(2)}
+	key := func(ops ...KeyOp) *ECDSAPublicKey {
+		return &ECDSAPublicKey{KeyDescription: KeyDescription{KeyOperations: ops}, PublicKey: pub}
+	}
+
+	set := Set{
+		key(KeyOpsSign, KeyOpsVerify),
+		key(KeyOpsDecrypt, KeyOpsUnwrapKey),
+		key(KeyOpsDeriveKey),
+		key(),
+	}
+
+	want := Set{
+		key(KeyOpsVerify),
+		key(KeyOpsEncrypt, KeyOpsKeyWrap),
+		key(),
+	}
+
+	if diff := deep.Equal(want, set.Public()); diff != nil {
+		t.Error(diff)
+	}
+
+	if ops := set[0].(*ECDSAPublicKey).KeyOperations; len(ops) != 2 {
+		t.Errorf("expected original key to be unmodified but got %v", ops)
+	}
+}
+
+func TestUnmarshalSet_unsupportedKeys(t *testing.T) {
+	data := []byte(`{"keys":[
+		{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo","kid":"ed"},
+		{"kty":"oct","k":"c2VjcmV0","kid":"oct"}
+	]}`)
+
+	var set Set
+	if err := set.UnmarshalJSON(data); !errors.Is(err, ErrUnsupportedKeyType) {
+		t.Errorf("expected ErrUnsupportedKeyType but got %v", err)
+	}
+
+	set, err := UnmarshalSet(data, UnmarshalSetOptions{SkipUnsupportedKeys: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(set) != 1 || set[0].ID() != "oct" {
+		t.Errorf("expected only the supported key but got %v", set)
+	}
+}
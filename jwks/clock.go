@@ -0,0 +1,47 @@
+package jwks
+
+import (
+	"context"
+	"time"
+
+	"github.com/halimath/jose/jwt"
+)
+
+// TimerClock defines the interface for clocks that also provide timers.
+// Remote.Run and Rotator.Run use After to wait if the configured Clock
+// implements TimerClock, so that a fake clock controls background refreshes
+// and rotations in tests. Other clocks wait using real timers.
+type TimerClock interface {
+	jwt.Clock
+
+	// After returns a channel that receives the current time once d has
+	// passed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// sleep waits for d using clock and returns true once d has passed. It
+// returns false if ctx is done before.
+func sleep(ctx context.Context, clock jwt.Clock, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	if tc, ok := clock.(TimerClock); ok {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-tc.After(d):
+			return true
+		}
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,4 @@
+// Package jwks implements publishing and consuming JSON Web Key Sets (JWKS)
+// as defined in RFC 7517 section 5 (https://datatracker.ietf.org/doc/html/rfc7517#section-5)
+// over HTTP as well as rotating the signing keys contained in them.
+package jwks
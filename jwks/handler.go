@@ -0,0 +1,168 @@
+package jwks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/halimath/jose/jwk"
+)
+
+const (
+	// ContentType is the media type of a JWK Set as registered in RFC 7517
+	// section 8.5.
+	ContentType = "application/jwk-set+json"
+
+	// DefaultMaxAge is the default duration clients may cache a published
+	// key set.
+	DefaultMaxAge = 15 * time.Minute
+)
+
+// HandlerOptions defines the options for a Handler. The zero value uses
+// DefaultMaxAge.
+type HandlerOptions struct {
+	// MaxAge is sent as the "max-age" directive of the Cache-Control
+	// header. If MaxAge is less than or equal to zero DefaultMaxAge is used.
+	MaxAge time.Duration
+}
+
+// Handler is an http.Handler that publishes a key set. Only the public
+// parts of the keys are published (see jwk.Set.Public). The key set can be
+// replaced at any time using SetKeys. The zero value publishes an empty key
+// set using DefaultMaxAge. A Handler is safe for concurrent use.
+type Handler struct {
+	cacheControl string
+	current      atomic.Value // of *publishedSet
+}
+
+type publishedSet struct {
+	keys jwk.Set
+	body []byte
+	etag string
+}
+
+var defaultCacheControl = cacheControlHeader(DefaultMaxAge)
+
+func cacheControlHeader(maxAge time.Duration) string {
+	return fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+}
+
+// NewHandler creates a Handler publishing the public keys from set.
+func NewHandler(set jwk.Set, opts HandlerOptions) (*Handler, error) {
+	maxAge := opts.MaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultMaxAge
+	}
+
+	h := &Handler{
+		cacheControl: cacheControlHeader(maxAge),
+	}
+
+	if err := h.SetKeys(set); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// SetKeys atomically replaces the published keys with the public keys from
+// set.
+func (h *Handler) SetKeys(set jwk.Set) error {
+	p, err := newPublishedSet(set)
+	if err != nil {
+		return err
+	}
+
+	h.current.Store(p)
+
+	return nil
+}
+
+func newPublishedSet(set jwk.Set) (*publishedSet, error) {
+	public := set.Public()
+
+	body, err := json.Marshal(public)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(body)
+
+	return &publishedSet{
+		keys: public,
+		body: body,
+		etag: `"` + hex.EncodeToString(sum[:16]) + `"`,
+	}, nil
+}
+
+// emptySet is published by a Handler whose keys have not been set.
+var emptySet, _ = newPublishedSet(jwk.Set{})
+
+// Keys returns the currently published keys.
+func (h *Handler) Keys() jwk.Set {
+	return h.published().keys
+}
+
+func (h *Handler) published() *publishedSet {
+	if p, ok := h.current.Load().(*publishedSet); ok {
+		return p
+	}
+	return emptySet
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	p := h.published()
+
+	cacheControl := h.cacheControl
+	if cacheControl == "" {
+		cacheControl = defaultCacheControl
+	}
+
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("ETag", p.etag)
+
+	if etagMatches(r.Header.Get("If-None-Match"), p.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("Content-Length", fmt.Sprint(len(p.body)))
+	w.WriteHeader(http.StatusOK)
+
+	if r.Method == http.MethodGet {
+		w.Write(p.body)
+	}
+}
+
+// etagMatches reports whether the If-None-Match header value ifNoneMatch
+// matches etag using the weak comparison defined in RFC 9110 section 8.8.3.2.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,161 @@
+package jwks
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/halimath/jose/jwk"
+)
+
+func generateECKey(t *testing.T, kid string) (*ecdsa.PrivateKey, *jwk.ECDSAPublicKey) {
+	t.Helper()
+
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pk, &jwk.ECDSAPublicKey{
+		KeyDescription: jwk.KeyDescription{KeyID: kid, KeyUse: jwk.UseSignature},
+		PublicKey:      &pk.PublicKey,
+	}
+}
+
+func TestHandler(t *testing.T) {
+	_, key1 := generateECKey(t, "1")
+	_, key2 := generateECKey(t, "2")
+
+	h, err := NewHandler(jwk.Set{key1, &jwk.SymmetricKey{Bytes: []byte("secret")}}, HandlerOptions{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := get("")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d but got %d", http.StatusOK, w.Code)
+	}
+
+	for header, want := range map[string]string{
+		"Content-Type":  ContentType,
+		"Cache-Control": "public, max-age=3600",
+	} {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("expected %s %q but got %q", header, want, got)
+		}
+	}
+
+	var got jwk.Set
+	if err := got.UnmarshalJSON(w.Body.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(jwk.Set{key1}, got); diff != nil {
+		t.Error(diff)
+	}
+
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected ETag")
+	}
+
+	t.Run("not modified", func(t *testing.T) {
+		for _, inm := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+			w := get(inm)
+			if w.Code != http.StatusNotModified {
+				t.Errorf("%s: expected status %d but got %d", inm, http.StatusNotModified, w.Code)
+			}
+			if w.Body.Len() != 0 {
+				t.Errorf("%s: expected empty body", inm)
+			}
+		}
+	})
+
+	t.Run("replace keys", func(t *testing.T) {
+		if err := h.SetKeys(jwk.Set{key1, key2}); err != nil {
+			t.Fatal(err)
+		}
+
+		w := get(etag)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d but got %d", http.StatusOK, w.Code)
+		}
+		if w.Header().Get("ETag") == etag {
+			t.Error("expected ETag to change")
+		}
+		if !strings.Contains(w.Body.String(), `"kid":"2"`) {
+			t.Errorf("expected new key to be published: %s", w.Body.String())
+		}
+		if len(h.Keys()) != 2 {
+			t.Errorf("expected 2 keys but got %d", len(h.Keys()))
+		}
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("expected status %d but got %d", http.StatusMethodNotAllowed, w.Code)
+		}
+	})
+}
+
+func TestHandler_stripsPrivateMembers(t *testing.T) {
+	h, err := NewHandler(jwk.Set{&jwk.AKPKey{
+		KeyDescription: jwk.KeyDescription{KeyID: "pq"},
+		Public:         []byte("public"),
+		Private:        []byte("private"),
+	}}, HandlerOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if strings.Contains(w.Body.String(), "priv") {
+		t.Errorf("expected private members to be stripped: %s", w.Body.String())
+	}
+
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=900" {
+		t.Errorf("unexpected Cache-Control: %q", got)
+	}
+}
+
+func TestHandler_zeroValue(t *testing.T) {
+	var h Handler
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d but got %d", http.StatusOK, w.Code)
+	}
+
+	if got := w.Body.String(); got != `{"keys":[]}` {
+		t.Errorf("unexpected body: %s", got)
+	}
+
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=900" {
+		t.Errorf("unexpected Cache-Control: %q", got)
+	}
+
+	if len(h.Keys()) != 0 {
+		t.Errorf("expected no keys but got %d", len(h.Keys()))
+	}
+}
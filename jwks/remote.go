@@ -0,0 +1,356 @@
+package jwks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/halimath/jose/jwk"
+	"github.com/halimath/jose/jws"
+	"github.com/halimath/jose/jwt"
+)
+
+const (
+	// DefaultMinRefreshInterval is the default minimum interval between two
+	// fetches of a remote key set.
+	DefaultMinRefreshInterval = 30 * time.Second
+
+	// DefaultMaxRefreshInterval is the default maximum duration a fetched
+	// key set is used before it is fetched again.
+	DefaultMaxRefreshInterval = 24 * time.Hour
+
+	// DefaultMaxResponseSize is the default maximum size in bytes of a
+	// remote key set.
+	DefaultMaxResponseSize = 1 << 20
+
+	// DefaultFetchTimeout is the default timeout of a single fetch of a
+	// remote key set.
+	DefaultFetchTimeout = 10 * time.Second
+)
+
+// ErrNotFetched is returned (maybe wrapped) from Remote if no key set has
+// been fetched successfully yet.
+var ErrNotFetched = errors.New("key set not fetched")
+
+// RemoteOptions defines the options for a Remote. The zero value uses the
+// defaults defined in this package.
+type RemoteOptions struct {
+	// Client is used to fetch the key set. If nil, http.DefaultClient is
+	// used.
+	Client *http.Client
+
+	// Timeout bounds each fetch of the key set, including fetches triggered
+	// by ResolveVerifiers, which has no context to cancel them. If Timeout
+	// is less than or equal to zero DefaultFetchTimeout is used.
+	Timeout time.Duration
+
+	// MaxAge defines how long a key set is used if the response carries
+	// neither a Cache-Control max-age directive nor an Expires header. If
+	// MaxAge is less than or equal to zero DefaultMaxAge is used.
+	MaxAge time.Duration
+
+	// MinRefreshInterval is the minimum interval between two fetches. It
+	// limits the rate of fetches triggered by tokens with unknown key IDs
+	// and is the interval to retry failed fetches. If MinRefreshInterval is
+	// less than or equal to zero DefaultMinRefreshInterval is used.
+	MinRefreshInterval time.Duration
+
+	// MaxRefreshInterval is the maximum duration a fetched key set is used
+	// regardless of caching headers. If MaxRefreshInterval is less than or
+	// equal to zero DefaultMaxRefreshInterval is used.
+	MaxRefreshInterval time.Duration
+
+	// MaxResponseSize is the maximum size in bytes of the key set document.
+	// If MaxResponseSize is less than or equal to zero
+	// DefaultMaxResponseSize is used.
+	MaxResponseSize int64
+
+	// Clock provides the time to determine expiration of fetched key sets.
+	// If nil, jwt.SystemClock is used.
+	Clock jwt.Clock
+}
+
+// Remote is a source of keys fetched from a remote JWKS URL. It caches the
+// key set according to the response's caching headers and serves the
+// cached keys if fetching fails. A Remote implements jwt.KeyResolver and
+// refetches the key set when asked for an unknown key ID, limited to one
+// fetch per MinRefreshInterval. A Remote is safe for concurrent use.
+type Remote struct {
+	url  string
+	opts RemoteOptions
+
+	fetchMu sync.Mutex
+
+	mu          sync.Mutex
+	keys        jwk.Set
+	etag        string
+	expiresAt   time.Time
+	lastAttempt time.Time
+	lastErr     error
+}
+
+// NewRemote creates a Remote fetching the key set from url. The key set is
+// fetched lazily when first needed; use Refresh to fetch it eagerly and Run
+// to refresh it in the background.
+func NewRemote(url string, opts RemoteOptions) *Remote {
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultFetchTimeout
+	}
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = DefaultMaxAge
+	}
+	if opts.MinRefreshInterval <= 0 {
+		opts.MinRefreshInterval = DefaultMinRefreshInterval
+	}
+	if opts.MaxRefreshInterval <= 0 {
+		opts.MaxRefreshInterval = DefaultMaxRefreshInterval
+	}
+	if opts.MaxResponseSize <= 0 {
+		opts.MaxResponseSize = DefaultMaxResponseSize
+	}
+	if opts.Clock == nil {
+		opts.Clock = jwt.SystemClock
+	}
+
+	return &Remote{
+		url:  url,
+		opts: opts,
+	}
+}
+
+// Keys returns the cached key set. If no key set has been fetched yet or the
+// cached key set expired, Keys fetches the key set. If fetching fails, the
+// stale key set is returned. An error is only returned if no key set has
+// ever been fetched successfully.
+func (r *Remote) Keys(ctx context.Context) (jwk.Set, error) {
+	r.mu.Lock()
+	keys, expiresAt, lastAttempt := r.keys, r.expiresAt, r.lastAttempt
+	r.mu.Unlock()
+
+	now := r.opts.Clock.Now()
+	if keys != nil && now.Before(expiresAt) {
+		return keys, nil
+	}
+
+	if lastAttempt.IsZero() || now.Sub(lastAttempt) >= r.opts.MinRefreshInterval {
+		r.fetch(ctx, lastAttempt)
+	}
+
+	return r.cached()
+}
+
+// Refresh fetches the key set regardless of the cached key set's expiration.
+// On failure, the cached key set is kept and the error is returned.
+func (r *Remote) Refresh(ctx context.Context) error {
+	r.mu.Lock()
+	lastAttempt := r.lastAttempt
+	r.mu.Unlock()
+
+	return r.fetch(ctx, lastAttempt)
+}
+
+// Run refreshes the key set in the background until ctx is done. It fetches
+// the key set whenever the cached one expires and retries failed fetches
+// after MinRefreshInterval. Run waits using the configured Clock if it
+// implements TimerClock. Run blocks; invoke it in its own goroutine.
+func (r *Remote) Run(ctx context.Context) {
+	for {
+		r.mu.Lock()
+		keys, expiresAt, lastErr, lastAttempt := r.keys, r.expiresAt, r.lastErr, r.lastAttempt
+		r.mu.Unlock()
+
+		var wait time.Duration
+		switch {
+		case keys == nil && lastAttempt.IsZero():
+			wait = 0
+		case lastErr != nil || keys == nil:
+			wait = lastAttempt.Add(r.opts.MinRefreshInterval).Sub(r.opts.Clock.Now())
+		default:
+			wait = expiresAt.Sub(r.opts.Clock.Now())
+		}
+
+		if !sleep(ctx, r.opts.Clock, wait) {
+			return
+		}
+
+		r.Refresh(ctx)
+	}
+}
+
+// ResolveVerifiers implements jwt.KeyResolver. If the token's header carries
+// a "kid" not contained in the cached key set, the key set is refetched
+// unless it has been fetched within MinRefreshInterval. Fetches are bounded
+// by the configured Timeout.
+func (r *Remote) ResolveVerifiers(header jws.Header, claims jwt.Claims) ([]jws.Verifier, error) {
+	ctx := context.Background()
+
+	keys, err := r.Keys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.KeyID != "" && !keys.Has(jwk.WithID(header.KeyID)) {
+		r.mu.Lock()
+		lastAttempt := r.lastAttempt
+		r.mu.Unlock()
+
+		if r.opts.Clock.Now().Sub(lastAttempt) >= r.opts.MinRefreshInterval {
+			r.fetch(ctx, lastAttempt)
+			if keys, err = r.cached(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return jwt.KeySetResolver(keys).ResolveVerifiers(header, claims)
+}
+
+func (r *Remote) cached() (jwk.Set, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.keys == nil {
+		if r.lastErr != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNotFetched, r.lastErr)
+		}
+		return nil, ErrNotFetched
+	}
+
+	return r.keys, nil
+}
+
+// fetch fetches the key set unless another fetch has been attempted since
+// seenAttempt, in which case the result of that fetch is used. Concurrent
+// calls are serialized so that at most one request is in flight.
+func (r *Remote) fetch(ctx context.Context, seenAttempt time.Time) error {
+	r.fetchMu.Lock()
+	defer r.fetchMu.Unlock()
+
+	r.mu.Lock()
+	if r.lastAttempt.After(seenAttempt) {
+		err := r.lastErr
+		r.mu.Unlock()
+		return err
+	}
+	etag := r.etag
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, r.opts.Timeout)
+	defer cancel()
+
+	now := r.opts.Clock.Now()
+	keys, newETag, maxAge, err := r.get(ctx, etag)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastAttempt = now
+	r.lastErr = err
+	if err != nil {
+		return err
+	}
+
+	if keys != nil {
+		r.keys = keys
+		r.etag = newETag
+	}
+	r.expiresAt = now.Add(maxAge)
+
+	return nil
+}
+
+// get performs the HTTP request. It returns a nil key set if the server
+// responded with 304 Not Modified.
+func (r *Remote) get(ctx context.Context, etag string) (jwk.Set, string, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	req.Header.Set("Accept", ContentType+", application/json")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	res, err := r.opts.Client.Do(req)
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("failed to fetch key set from %s: %w", r.url, err)
+	}
+	defer res.Body.Close()
+
+	maxAge := r.maxAge(res.Header)
+
+	if res.StatusCode == http.StatusNotModified && etag != "" {
+		return nil, etag, maxAge, nil
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, "", 0, fmt.Errorf("failed to fetch key set from %s: unexpected status %d", r.url, res.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, r.opts.MaxResponseSize+1))
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("failed to fetch key set from %s: %w", r.url, err)
+	}
+	if int64(len(body)) > r.opts.MaxResponseSize {
+		return nil, "", 0, fmt.Errorf("failed to fetch key set from %s: response exceeds %d bytes", r.url, r.opts.MaxResponseSize)
+	}
+
+	// Ignore keys of types not understood as recommended by RFC 7517
+	// section 5, e.g. if a third party publishes OKP keys
+	keys, err := jwk.UnmarshalSet(body, jwk.UnmarshalSetOptions{SkipUnsupportedKeys: true})
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("failed to parse key set from %s: %w", r.url, err)
+	}
+
+	return keys, res.Header.Get("ETag"), maxAge, nil
+}
+
+// maxAge determines how long a response with header may be cached based on
+// the Cache-Control and Expires headers, bounded by the configured refresh
+// intervals.
+func (r *Remote) maxAge(header http.Header) time.Duration {
+	maxAge := r.opts.MaxAge
+	cacheControlSet := false
+
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store", "no-cache":
+			maxAge = 0
+			cacheControlSet = true
+		case "max-age":
+			if secs, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil {
+				maxAge = time.Duration(secs) * time.Second
+				cacheControlSet = true
+			}
+		}
+	}
+
+	if !cacheControlSet {
+		if expires, err := http.ParseTime(header.Get("Expires")); err == nil {
+			date, err := http.ParseTime(header.Get("Date"))
+			if err != nil {
+				date = r.opts.Clock.Now()
+			}
+			maxAge = expires.Sub(date)
+		}
+	}
+
+	if maxAge < r.opts.MinRefreshInterval {
+		maxAge = r.opts.MinRefreshInterval
+	}
+	if maxAge > r.opts.MaxRefreshInterval {
+		maxAge = r.opts.MaxRefreshInterval
+	}
+
+	return maxAge
+}
@@ -0,0 +1,387 @@
+package jwks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/halimath/jose/jwk"
+	"github.com/halimath/jose/jws"
+	"github.com/halimath/jose/jwt"
+)
+
+// testServer serves a key set using a Handler and counts requests. If
+// failing is set, it responds with 500.
+type testServer struct {
+	*httptest.Server
+	handler  *Handler
+	requests int32
+	failing  int32
+}
+
+func newTestServer(t *testing.T, set jwk.Set, maxAge time.Duration) *testServer {
+	t.Helper()
+
+	h, err := NewHandler(set, HandlerOptions{MaxAge: maxAge})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &testServer{handler: h}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.requests, 1)
+		if atomic.LoadInt32(&s.failing) != 0 {
+			http.Error(w, "failing", http.StatusInternalServerError)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *testServer) count() int32 {
+	return atomic.LoadInt32(&s.requests)
+}
+
+// testClock is a TimerClock whose time only advances using advance. If
+// waits is non-nil, each call to After is reported on it.
+type testClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []testTimer
+	waits   chan time.Duration
+}
+
+type testTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	t := testTimer{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	c.waiters = append(c.waiters, t)
+	c.mu.Unlock()
+
+	if c.waits != nil {
+		c.waits <- d
+	}
+	return t.c
+}
+
+// advance advances the clock by d and fires all timers that are due.
+func (c *testClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	pending := c.waiters[:0]
+	for _, t := range c.waiters {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+		} else {
+			t.c <- c.now
+		}
+	}
+	c.waiters = pending
+}
+
+func signToken(t *testing.T, key *ecdsa.PrivateKey, kid string) *jwt.Token {
+	t.Helper()
+
+	signer, err := jws.ES256Signer(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := jwt.Builder{Signer: signer, KeyID: kid}
+	token, err := b.Sign(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func TestRemote(t *testing.T) {
+	priv1, key1 := generateECKey(t, "1")
+	priv2, key2 := generateECKey(t, "2")
+
+	server := newTestServer(t, jwk.Set{key1}, time.Hour)
+	clock := &testClock{now: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+	remote := NewRemote(server.URL, RemoteOptions{
+		MinRefreshInterval: time.Minute,
+		Clock:              clock,
+	})
+	ctx := context.Background()
+
+	t.Run("fetch and cache", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			keys, err := remote.Keys(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(keys) != 1 || keys[0].ID() != "1" {
+				t.Errorf("unexpected keys: %v", keys)
+			}
+		}
+		if n := server.count(); n != 1 {
+			t.Errorf("expected a single fetch but got %d", n)
+		}
+
+		if err := signToken(t, priv1, "1").Verify(jwt.SignatureFromResolver(remote)); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("unknown kid", func(t *testing.T) {
+		server.handler.SetKeys(jwk.Set{key1, key2})
+		token := signToken(t, priv2, "2")
+
+		clock.advance(30 * time.Second)
+		if err := token.Verify(jwt.SignatureFromResolver(remote)); !errors.Is(err, jwt.ErrNoKey) {
+			t.Errorf("expected rate limited refetch and ErrNoKey but got %v", err)
+		}
+		if n := server.count(); n != 1 {
+			t.Errorf("expected no additional fetch but got %d", n)
+		}
+
+		clock.advance(30 * time.Second)
+		if err := token.Verify(jwt.SignatureFromResolver(remote)); err != nil {
+			t.Error(err)
+		}
+		if n := server.count(); n != 2 {
+			t.Errorf("expected a refetch but got %d fetches", n)
+		}
+	})
+
+	t.Run("expiration and not modified", func(t *testing.T) {
+		clock.advance(time.Hour)
+		keys, err := remote.Keys(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 2 {
+			t.Errorf("expected keys to be retained but got %v", keys)
+		}
+		if n := server.count(); n != 3 {
+			t.Errorf("expected a refetch after expiration but got %d fetches", n)
+		}
+	})
+
+	t.Run("stale on failure", func(t *testing.T) {
+		atomic.StoreInt32(&server.failing, 1)
+		defer atomic.StoreInt32(&server.failing, 0)
+
+		clock.advance(2 * time.Hour)
+
+		keys, err := remote.Keys(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 2 {
+			t.Errorf("expected stale keys but got %v", keys)
+		}
+
+		if err := remote.Refresh(ctx); err == nil {
+			t.Error("expected refresh to fail")
+		}
+
+		if err := signToken(t, priv1, "1").Verify(jwt.SignatureFromResolver(remote)); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestRemote_notFetched(t *testing.T) {
+	server := newTestServer(t, jwk.Set{}, time.Hour)
+	atomic.StoreInt32(&server.failing, 1)
+
+	remote := NewRemote(server.URL, RemoteOptions{MinRefreshInterval: time.Hour})
+
+	for i := 0; i < 3; i++ {
+		if _, err := remote.Keys(context.Background()); !errors.Is(err, ErrNotFetched) {
+			t.Errorf("expected ErrNotFetched but got %v", err)
+		}
+	}
+
+	if n := server.count(); n != 1 {
+		t.Errorf("expected failed fetches to be rate limited but got %d fetches", n)
+	}
+}
+
+func TestRemote_concurrent(t *testing.T) {
+	_, key := generateECKey(t, "1")
+
+	h, err := NewHandler(jwk.Set{key}, HandlerOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first request blocks until all goroutines have been started so
+	// that they compete for the fetch
+	release := make(chan struct{})
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		h.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	remote := NewRemote(server.URL, RemoteOptions{})
+
+	var started, wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		started.Add(1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			started.Done()
+			if _, err := remote.Keys(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	started.Wait()
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("expected a single fetch but got %d", n)
+	}
+}
+
+func TestRemote_Run(t *testing.T) {
+	_, key := generateECKey(t, "1")
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Content-Type", ContentType)
+		data, _ := jwk.Set{key}.MarshalJSON()
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	clock := &testClock{now: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC), waits: make(chan time.Duration, 1)}
+	remote := NewRemote(server.URL, RemoteOptions{MinRefreshInterval: time.Minute, Clock: clock})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		remote.Run(ctx)
+		close(done)
+	}()
+
+	for i := 1; i <= 3; i++ {
+		if d := <-clock.waits; d != time.Minute {
+			t.Errorf("expected to wait for %s but got %s", time.Minute, d)
+		}
+
+		if n := atomic.LoadInt32(&requests); n != int32(i) {
+			t.Errorf("expected %d fetches but got %d", i, n)
+		}
+
+		clock.advance(time.Minute)
+	}
+
+	<-clock.waits
+	cancel()
+	<-done
+}
+
+func TestRemote_unsupportedKeys(t *testing.T) {
+	_, key := generateECKey(t, "1")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		w.Write([]byte(`{"keys":[{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo","kid":"ed"},`))
+		data, _ := json.Marshal(key)
+		w.Write(data)
+		w.Write([]byte(`]}`))
+	}))
+	defer server.Close()
+
+	keys, err := NewRemote(server.URL, RemoteOptions{}).Keys(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 1 || keys[0].ID() != "1" {
+		t.Errorf("expected unsupported key to be skipped but got %v", keys)
+	}
+}
+
+func TestRemote_timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	remote := NewRemote(server.URL, RemoteOptions{Timeout: 10 * time.Millisecond})
+
+	_, err := remote.ResolveVerifiers(jws.Header{KeyID: "1"}, nil)
+	if !errors.Is(err, ErrNotFetched) || !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Errorf("expected fetch to time out but got %v", err)
+	}
+}
+
+func TestRemote_maxAge(t *testing.T) {
+	remote := NewRemote("", RemoteOptions{
+		MaxAge:             time.Hour,
+		MinRefreshInterval: time.Minute,
+		MaxRefreshInterval: 12 * time.Hour,
+	})
+
+	tests := map[string]struct {
+		header http.Header
+		want   time.Duration
+	}{
+		"default":  {http.Header{}, time.Hour},
+		"max-age":  {http.Header{"Cache-Control": {"public, max-age=600"}}, 10 * time.Minute},
+		"no-cache": {http.Header{"Cache-Control": {"no-cache"}}, time.Minute},
+		"too long": {http.Header{"Cache-Control": {"max-age=86400"}}, 12 * time.Hour},
+		"expires": {http.Header{
+			"Date":    {"Wed, 01 Jan 2020 12:00:00 GMT"},
+			"Expires": {"Wed, 01 Jan 2020 12:30:00 GMT"},
+		}, 30 * time.Minute},
+		"max-age precedes expires": {http.Header{
+			"Cache-Control": {"max-age=120"},
+			"Date":          {"Wed, 01 Jan 2020 12:00:00 GMT"},
+			"Expires":       {"Wed, 01 Jan 2020 12:30:00 GMT"},
+		}, 2 * time.Minute},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := remote.maxAge(test.header); got != test.want {
+				t.Errorf("expected %s but got %s", test.want, got)
+			}
+		})
+	}
+}
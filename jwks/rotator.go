@@ -0,0 +1,321 @@
+package jwks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/halimath/jose/jwk"
+	"github.com/halimath/jose/jws"
+	"github.com/halimath/jose/jwt"
+)
+
+const (
+	// DefaultRotationInterval is the default interval after which a Rotator
+	// replaces the active key.
+	DefaultRotationInterval = 24 * time.Hour
+
+	// DefaultGracePeriod is the default duration a Rotator keeps publishing
+	// a key after it has been replaced.
+	DefaultGracePeriod = 24 * time.Hour
+)
+
+// KeyGenerator defines the interface for types that generate fresh signing
+// keys. GenerateKey returns a signer and the corresponding public key which
+// must carry kid as its key ID.
+type KeyGenerator interface {
+	GenerateKey(kid string) (jws.Signer, jwk.Key, error)
+}
+
+// KeyGeneratorFunc is a convenience type that wraps a single function as a
+// KeyGenerator.
+type KeyGeneratorFunc func(kid string) (jws.Signer, jwk.Key, error)
+
+func (f KeyGeneratorFunc) GenerateKey(kid string) (jws.Signer, jwk.Key, error) {
+	return f(kid)
+}
+
+// ES256KeyGenerator is a KeyGenerator that generates ECDSA keys using
+// P-256 to be used with ES256.
+var ES256KeyGenerator KeyGenerator = KeyGeneratorFunc(func(kid string) (jws.Signer, jwk.Key, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signer, err := jws.ES256Signer(privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return signer, &jwk.ECDSAPublicKey{
+		KeyDescription: jwk.KeyDescription{
+			KeyUse:       jwk.UseSignature,
+			KeyAlgorithm: string(jws.ALG_ES256),
+			KeyID:        kid,
+		},
+		PublicKey: &privateKey.PublicKey,
+	}, nil
+})
+
+// RotatorOptions defines the options for a Rotator. The zero value uses the
+// defaults defined in this package.
+type RotatorOptions struct {
+	// Interval is the duration a key is used for signing before it is
+	// replaced. If Interval is less than or equal to zero
+	// DefaultRotationInterval is used.
+	Interval time.Duration
+
+	// GracePeriod is the duration a replaced key is still published. It
+	// should exceed the lifetime of the tokens plus the time verifiers cache
+	// the key set. If GracePeriod is less than or equal to zero
+	// DefaultGracePeriod is used.
+	GracePeriod time.Duration
+
+	// KeyGenerator generates the keys. If nil, ES256KeyGenerator is used.
+	KeyGenerator KeyGenerator
+
+	// KeyIDGenerator generates the key IDs. If nil, jwt.RandomIDGenerator
+	// is used.
+	KeyIDGenerator jwt.IDGenerator
+
+	// Clock provides the time to determine when to rotate keys and when
+	// to stop publishing replaced keys. If nil, jwt.SystemClock is used.
+	Clock jwt.Clock
+
+	// OnRotate is invoked with the public key set whenever it changes, i.e.
+	// once the initial key has been generated, after each rotation and
+	// after replaced keys have been removed at the end of their grace
+	// period, e.g. to update a Handler using SetKeys. Invocations are
+	// serialized and deliver the key sets in order.
+	OnRotate func(jwk.Set)
+}
+
+// Rotator manages a set of signing keys. It signs with a single active key
+// and replaces it with a freshly generated key either on demand using
+// Rotate or periodically using Run. Replaced keys are kept in the published
+// key set for the grace period so tokens signed with them can still be
+// verified. A Rotator is safe for concurrent use.
+type Rotator struct {
+	opts RotatorOptions
+
+	rotateMu sync.Mutex
+	notifyMu sync.Mutex
+
+	mu      sync.Mutex
+	active  *rotatedKey
+	retired []*rotatedKey
+}
+
+type rotatedKey struct {
+	signer    jws.Signer
+	key       jwk.Key
+	kid       string
+	createdAt time.Time
+	retiredAt time.Time
+}
+
+// NewRotator creates a Rotator and generates its initial active key.
+func NewRotator(opts RotatorOptions) (*Rotator, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultRotationInterval
+	}
+	if opts.GracePeriod <= 0 {
+		opts.GracePeriod = DefaultGracePeriod
+	}
+	if opts.KeyGenerator == nil {
+		opts.KeyGenerator = ES256KeyGenerator
+	}
+	if opts.KeyIDGenerator == nil {
+		opts.KeyIDGenerator = jwt.RandomIDGenerator
+	}
+	if opts.Clock == nil {
+		opts.Clock = jwt.SystemClock
+	}
+
+	r := &Rotator{opts: opts}
+
+	k, err := r.generate()
+	if err != nil {
+		return nil, err
+	}
+	r.active = k
+
+	r.notify()
+
+	return r, nil
+}
+
+func (r *Rotator) generate() (*rotatedKey, error) {
+	kid, err := r.opts.KeyIDGenerator.GenerateID()
+	if err != nil {
+		return nil, err
+	}
+
+	signer, key, err := r.opts.KeyGenerator.GenerateKey(kid)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.ID() != kid {
+		return nil, errors.New("generated key does not carry the requested key ID")
+	}
+
+	return &rotatedKey{
+		signer:    signer,
+		key:       key,
+		kid:       kid,
+		createdAt: r.opts.Clock.Now(),
+	}, nil
+}
+
+// Rotate generates a new key and makes it the active key. The previously
+// active key is published for the grace period. If generating the key
+// fails, the active key is kept and the error is returned.
+func (r *Rotator) Rotate() error {
+	r.rotateMu.Lock()
+	defer r.rotateMu.Unlock()
+
+	k, err := r.generate()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.active.retiredAt = k.createdAt
+	r.retired = append(r.retired, r.active)
+	r.active = k
+	r.prune(k.createdAt)
+	r.mu.Unlock()
+
+	r.notify()
+
+	return nil
+}
+
+// notify invokes OnRotate with the current key set. The key set is obtained
+// while holding notifyMu so that concurrent invocations deliver the key sets
+// in order.
+func (r *Rotator) notify() {
+	if r.opts.OnRotate == nil {
+		return
+	}
+
+	r.notifyMu.Lock()
+	defer r.notifyMu.Unlock()
+
+	r.mu.Lock()
+	keys := r.keys()
+	r.mu.Unlock()
+
+	r.opts.OnRotate(keys)
+}
+
+// prune removes retired keys whose grace period has passed and reports
+// whether any key has been removed. r.mu must be held.
+func (r *Rotator) prune(now time.Time) bool {
+	n := len(r.retired)
+	retained := r.retired[:0]
+	for _, k := range r.retired {
+		if now.Before(k.retiredAt.Add(r.opts.GracePeriod)) {
+			retained = append(retained, k)
+		}
+	}
+	for i := len(retained); i < len(r.retired); i++ {
+		r.retired[i] = nil
+	}
+	r.retired = retained
+	return len(retained) != n
+}
+
+// keys returns the public key set with the active key first. r.mu must be
+// held.
+func (r *Rotator) keys() jwk.Set {
+	set := make(jwk.Set, 0, len(r.retired)+1)
+	set = append(set, r.active.key)
+	for i := len(r.retired) - 1; i >= 0; i-- {
+		set = append(set, r.retired[i].key)
+	}
+	return set.Public()
+}
+
+// Keys returns the public key set to publish. It contains the active key
+// followed by the replaced keys still within their grace period. If replaced
+// keys have been removed, OnRotate is invoked.
+func (r *Rotator) Keys() jwk.Set {
+	r.mu.Lock()
+	pruned := r.prune(r.opts.Clock.Now())
+	keys := r.keys()
+	r.mu.Unlock()
+
+	if pruned {
+		r.notify()
+	}
+
+	return keys
+}
+
+// Active returns the active signer and its key ID.
+func (r *Rotator) Active() (jws.Signer, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.active.signer, r.active.kid
+}
+
+// Sign signs claims using the active key. It uses b as a template for the
+// other claims and header parameters; its Signer and KeyID are replaced
+// with the active key's so the token's "kid" always matches the signing
+// key.
+func (r *Rotator) Sign(b jwt.Builder, claims any) (*jwt.Token, error) {
+	b.Signer, b.KeyID = r.Active()
+	return b.Sign(claims)
+}
+
+// Run rotates the active key whenever it has been in use for Interval and
+// removes replaced keys at the end of their grace period until ctx is done.
+// If generating a key fails, Run retries after a minute. Run waits using the
+// configured Clock if it implements TimerClock. Run blocks; invoke it in its
+// own goroutine.
+func (r *Rotator) Run(ctx context.Context) {
+	for {
+		if !sleep(ctx, r.opts.Clock, r.next().Sub(r.opts.Clock.Now())) {
+			return
+		}
+
+		r.mu.Lock()
+		due := !r.opts.Clock.Now().Before(r.active.createdAt.Add(r.opts.Interval))
+		r.mu.Unlock()
+
+		if !due {
+			r.Keys()
+			continue
+		}
+
+		if err := r.Rotate(); err != nil {
+			if !sleep(ctx, r.opts.Clock, time.Minute) {
+				return
+			}
+		}
+	}
+}
+
+// next returns the time of the next rotation or the end of a replaced key's
+// grace period, whichever comes first.
+func (r *Rotator) next() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next := r.active.createdAt.Add(r.opts.Interval)
+	for _, k := range r.retired {
+		if end := k.retiredAt.Add(r.opts.GracePeriod); end.Before(next) {
+			next = end
+		}
+	}
+
+	return next
+}
@@ -0,0 +1,190 @@
+package jwks
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/halimath/jose/jwk"
+	"github.com/halimath/jose/jws"
+	"github.com/halimath/jose/jwt"
+)
+
+func keyIDs(set jwk.Set) []string {
+	ids := make([]string, len(set))
+	for i, k := range set {
+		ids[i] = k.ID()
+	}
+	return ids
+}
+
+func TestRotator(t *testing.T) {
+	clock := &testClock{now: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+	var kids int32
+	var published jwk.Set
+
+	r, err := NewRotator(RotatorOptions{
+		GracePeriod: time.Hour,
+		Clock:       clock,
+		KeyIDGenerator: jwt.IDGeneratorFunc(func() (string, error) {
+			return string(rune('a' + atomic.AddInt32(&kids, 1) - 1)), nil
+		}),
+		OnRotate: func(set jwk.Set) { published = set },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := keyIDs(published); len(got) != 1 || got[0] != "a" {
+		t.Errorf("expected published keys [a] but got %v", got)
+	}
+
+	sign := func() *jwt.Token {
+		t.Helper()
+		token, err := r.Sign(jwt.Builder{TTL: time.Minute, Clock: clock}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	verify := func(token *jwt.Token) error {
+		return token.Verify(jwt.SignatureFromResolver(jwt.KeySetResolver(r.Keys())))
+	}
+
+	first := sign()
+	if kid := first.Header().KeyID; kid != "a" {
+		t.Errorf("expected kid a but got %q", kid)
+	}
+
+	if err := r.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := keyIDs(published); len(got) != 2 || got[0] != "b" || got[1] != "a" {
+		t.Errorf("expected published keys [b a] but got %v", got)
+	}
+
+	second := sign()
+	if kid := second.Header().KeyID; kid != "b" {
+		t.Errorf("expected kid b but got %q", kid)
+	}
+
+	t.Run("grace period", func(t *testing.T) {
+		if err := verify(first); err != nil {
+			t.Error(err)
+		}
+		if err := verify(second); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("after grace period", func(t *testing.T) {
+		clock.advance(time.Hour)
+
+		if got := keyIDs(r.Keys()); len(got) != 1 || got[0] != "b" {
+			t.Errorf("expected keys [b] but got %v", got)
+		}
+		if got := keyIDs(published); len(got) != 1 || got[0] != "b" {
+			t.Errorf("expected published keys [b] but got %v", got)
+		}
+		if err := verify(first); err == nil {
+			t.Error("expected error but got nil")
+		}
+		if err := verify(second); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("public keys only", func(t *testing.T) {
+		for _, k := range r.Keys() {
+			if k.Type() != jwk.KeyTypeEC {
+				t.Errorf("unexpected key type %s", k.Type())
+			}
+		}
+	})
+}
+
+func TestRotator_generatorFailure(t *testing.T) {
+	var fail int32
+	r, err := NewRotator(RotatorOptions{
+		KeyGenerator: KeyGeneratorFunc(func(kid string) (jws.Signer, jwk.Key, error) {
+			if atomic.LoadInt32(&fail) != 0 {
+				return nil, nil, errors.New("failed")
+			}
+			return ES256KeyGenerator.GenerateKey(kid)
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, kid := r.Active()
+
+	atomic.StoreInt32(&fail, 1)
+	if err := r.Rotate(); err == nil {
+		t.Error("expected error but got nil")
+	}
+
+	if _, got := r.Active(); got != kid {
+		t.Errorf("expected active key %q to be kept but got %q", kid, got)
+	}
+}
+
+func TestRotator_Run(t *testing.T) {
+	clock := &testClock{now: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC), waits: make(chan time.Duration, 1)}
+
+	var kids int32
+	var published jwk.Set
+
+	r, err := NewRotator(RotatorOptions{
+		Interval:    time.Hour,
+		GracePeriod: 30 * time.Minute,
+		Clock:       clock,
+		KeyIDGenerator: jwt.IDGeneratorFunc(func() (string, error) {
+			return string(rune('a' + atomic.AddInt32(&kids, 1) - 1)), nil
+		}),
+		OnRotate: func(set jwk.Set) { published = set },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+
+	steps := []struct {
+		wait time.Duration
+		want []string
+	}{
+		{time.Hour, []string{"b", "a"}},
+		{30 * time.Minute, []string{"b"}},
+		{30 * time.Minute, []string{"c", "b"}},
+	}
+
+	wait := <-clock.waits
+	for _, step := range steps {
+		if wait != step.wait {
+			t.Fatalf("expected to wait for %s but got %s", step.wait, wait)
+		}
+
+		clock.advance(wait)
+
+		// Run has handled the timer once it waits again
+		wait = <-clock.waits
+		if diff := deep.Equal(keyIDs(published), step.want); diff != nil {
+			t.Errorf("after %s: %v", step.wait, diff)
+		}
+	}
+
+	cancel()
+	<-done
+}
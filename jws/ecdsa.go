@@ -132,6 +132,8 @@ func ESVerifier(alg SignatureAlgorithm, publicKey *ecdsa.PublicKey) (Verifier, e
 	switch alg {
 	case ALG_ES256:
 		return ES256Verifier(publicKey)
+	case ALG_ES384:
+		return ES384Verifier(publicKey)
 	case ALG_ES512:
 		return ES512Verifier(publicKey)
 	default:
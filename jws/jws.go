@@ -12,6 +12,8 @@ import (
 	"strings"
 
 	"github.com/halimath/jose/internal/encoding"
+	"github.com/halimath/jose/internal/sentinel"
+	"github.com/halimath/jose/internal/strictjson"
 )
 
 var (
@@ -30,6 +32,18 @@ type Header struct {
 	Algorithm SignatureAlgorithm `json:"alg"`
 	Type      string             `json:"typ,omitempty"`
 
+	// The "kid" (key ID) Header Parameter is a hint indicating which key
+	// was used to secure the JWS. When used with a JWK, the "kid" value is
+	// used to match a JWK "kid" parameter value. See RFC 7515 section 4.1.4
+	// (https://datatracker.ietf.org/doc/html/rfc7515#section-4.1.4)
+	KeyID string `json:"kid,omitempty"`
+
+	// The "cty" (content type) Header Parameter is used by this application
+	// to declare the media type of the secured content (the payload). See
+	// RFC 7515 section 4.1.10
+	// (https://datatracker.ietf.org/doc/html/rfc7515#section-4.1.10)
+	ContentType string `json:"cty,omitempty"`
+
 	// TODO: Add standard fields
 	// 	4.1.2.  "jku" (JWK Set URL) Header Parameter
 
@@ -51,17 +65,6 @@ type Header struct {
 	// 	represented as a JSON Web Key [JWK].  Use of this Header Parameter is
 	// 	OPTIONAL.
 
-	//  4.1.4.  "kid" (Key ID) Header Parameter
-
-	// 	The "kid" (key ID) Header Parameter is a hint indicating which key
-	// 	was used to secure the JWS.  This parameter allows originators to
-	// 	explicitly signal a change of key to recipients.  The structure of
-	// 	the "kid" value is unspecified.  Its value MUST be a case-sensitive
-	// 	string.  Use of this Header Parameter is OPTIONAL.
-
-	// 	When used with a JWK, the "kid" value is used to match a JWK "kid"
-	// 	parameter value.
-
 	//  4.1.5.  "x5u" (X.509 URL) Header Parameter
 
 	// 	The "x5u" (X.509 URL) Header Parameter is a URI [RFC3986] that refers
@@ -231,32 +234,144 @@ func Sign(signer Signer, payload []byte, header Header) (*JWS, error) {
 	}, nil
 }
 
+const (
+	// DefaultMaxTokenSize is the default maximum length of a JWS in compact
+	// serialization.
+	DefaultMaxTokenSize = 1 << 20
+
+	// DefaultMaxHeaderSize is the default maximum size in bytes of a
+	// decoded JOSE header.
+	DefaultMaxHeaderSize = 8 << 10
+
+	// DefaultMaxPayloadSize is the default maximum size in bytes of a
+	// decoded payload.
+	DefaultMaxPayloadSize = 1 << 20
+)
+
+// ParseOptions defines options to control parsing a JWS in compact
+// serialization. The zero value applies the default size limits, rejects
+// headers with duplicate member names and requires canonical base64url
+// encoding.
+type ParseOptions struct {
+	// MaxTokenSize is the maximum length of the compact serialization. If
+	// MaxTokenSize is less than or equal to zero DefaultMaxTokenSize is used.
+	MaxTokenSize int
+
+	// MaxHeaderSize is the maximum size in bytes of the decoded header. If
+	// MaxHeaderSize is less than or equal to zero DefaultMaxHeaderSize is
+	// used.
+	MaxHeaderSize int
+
+	// MaxPayloadSize is the maximum size in bytes of the decoded payload. If
+	// MaxPayloadSize is less than or equal to zero DefaultMaxPayloadSize is
+	// used.
+	MaxPayloadSize int
+
+	// AllowDuplicateMembers makes parsing accept headers containing
+	// duplicate member names, which RFC 7515 section 4 requires to be
+	// rejected. If set, the last member wins.
+	AllowDuplicateMembers bool
+
+	// AllowNonCanonicalEncoding makes parsing accept base64url encoded parts
+	// that are not canonically encoded, e.g. because of non-zero padding
+	// bits.
+	AllowNonCanonicalEncoding bool
+}
+
+func (o ParseOptions) maxTokenSize() int {
+	if o.MaxTokenSize <= 0 {
+		return DefaultMaxTokenSize
+	}
+	return o.MaxTokenSize
+}
+
+func (o ParseOptions) maxHeaderSize() int {
+	if o.MaxHeaderSize <= 0 {
+		return DefaultMaxHeaderSize
+	}
+	return o.MaxHeaderSize
+}
+
+func (o ParseOptions) maxPayloadSize() int {
+	if o.MaxPayloadSize <= 0 {
+		return DefaultMaxPayloadSize
+	}
+	return o.MaxPayloadSize
+}
+
+// decode decodes the base64url encoded part named name enforcing maxSize.
+func (o ParseOptions) decode(name, part string, maxSize int) ([]byte, error) {
+	if encoding.DecodedLen(len(part)) > maxSize {
+		return nil, fmt.Errorf("%w: %s exceeds maximum size of %d bytes", ErrInvalidCompactJWS, name, maxSize)
+	}
+
+	var b []byte
+	var err error
+	if o.AllowNonCanonicalEncoding {
+		b, err = encoding.Decode(part)
+	} else {
+		b, err = encoding.DecodeCanonical(part)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid %s: %s", ErrInvalidCompactJWS, name, err)
+	}
+
+	return b, nil
+}
+
 // ParseCompact parses the given compact representation into a JWS datastructure and returns it.
 // It performs only a syntactically validation of base64 URL encoded data as well as parsing
 // the JOSE header JSON. The signature ist NOT verified. Use Verify to perform the verification.
+//
+// ParseCompact applies the default ParseOptions, i.e. it enforces the
+// default size limits, rejects headers with duplicate member names and
+// requires canonical base64url encoding. Earlier versions accepted such
+// input; use ParseCompactWithOptions to relax these checks.
 func ParseCompact(compact string) (*JWS, error) {
+	return ParseCompactWithOptions(compact, ParseOptions{})
+}
+
+// ParseCompactWithOptions works like ParseCompact but applies opts. All
+// errors returned wrap ErrInvalidCompactJWS; errors caused by the header
+// also wrap ErrInvalidHeader.
+func ParseCompactWithOptions(compact string, opts ParseOptions) (*JWS, error) {
+	if len(compact) > opts.maxTokenSize() {
+		return nil, fmt.Errorf("%w: token exceeds maximum size of %d bytes", ErrInvalidCompactJWS, opts.maxTokenSize())
+	}
+
 	parts := strings.Split(compact, ".")
-	if len(parts) < 2 {
+	if len(parts) != 3 {
 		return nil, fmt.Errorf("%w: invalid number of encoded parts: %d", ErrInvalidCompactJWS, len(parts))
 	}
 
-	header, err := DecodeHeader(parts[0])
+	headerData, err := opts.decode("header", parts[0], opts.maxHeaderSize())
 	if err != nil {
-		return nil, fmt.Errorf("%w: %s", ErrInvalidCompactJWS, err)
+		return nil, err
+	}
+
+	if !opts.AllowDuplicateMembers {
+		if err := strictjson.CheckDuplicateMembers(headerData); err != nil {
+			return nil, sentinel.Wrap(ErrInvalidCompactJWS, fmt.Errorf("%w: %s", ErrInvalidHeader, err))
+		}
 	}
 
-	payload, err := encoding.Decode(parts[1])
+	var header Header
+	if err := json.Unmarshal(headerData, &header); err != nil {
+		return nil, sentinel.Wrap(ErrInvalidCompactJWS, fmt.Errorf("%w: %s", ErrInvalidHeader, err))
+	}
+
+	payload, err := opts.decode("payload", parts[1], opts.maxPayloadSize())
 	if err != nil {
-		return nil, fmt.Errorf("%w: %s", ErrInvalidCompactJWS, err)
+		return nil, err
 	}
 
-	signature, err := encoding.Decode(parts[2])
+	signature, err := opts.decode("signature", parts[2], opts.maxTokenSize())
 	if err != nil {
-		return nil, fmt.Errorf("%w: %s", ErrInvalidCompactJWS, err)
+		return nil, err
 	}
 
 	return &JWS{
-		header:           *header,
+		header:           header,
 		headerEncoded:    parts[0],
 		payload:          payload,
 		payloadEncoded:   parts[1],
@@ -1,9 +1,12 @@
 package jws
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/go-test/deep"
+	"github.com/halimath/jose/internal/encoding"
 )
 
 func TestHeader(t *testing.T) {
@@ -112,3 +115,58 @@ func TestSignEncodeDecodeVerify(t *testing.T) {
 		})
 	}
 }
+
+func TestParseCompactWithOptions(t *testing.T) {
+	enc := func(s string) string {
+		return encoding.Encode([]byte(s))
+	}
+
+	header := enc(`{"alg":"none"}`)
+	payload := enc("payload")
+
+	tests := map[string]struct {
+		compact string
+		opts    ParseOptions
+		valid   bool
+	}{
+		"valid":                 {header + "." + payload + ".", ParseOptions{}, true},
+		"one part":              {header, ParseOptions{}, false},
+		"two parts":             {header + "." + payload, ParseOptions{}, false},
+		"four parts":            {header + "." + payload + "..", ParseOptions{}, false},
+		"token too large":       {header + "." + payload + ".", ParseOptions{MaxTokenSize: 10}, false},
+		"header too large":      {header + "." + payload + ".", ParseOptions{MaxHeaderSize: 8}, false},
+		"payload too large":     {header + "." + payload + ".", ParseOptions{MaxPayloadSize: 4}, false},
+		"payload at limit":      {header + "." + payload + ".", ParseOptions{MaxPayloadSize: 7}, true},
+		"duplicate header":      {enc(`{"alg":"none","alg":"HS256"}`) + "." + payload + ".", ParseOptions{}, false},
+		"duplicate allowed":     {enc(`{"alg":"HS256","alg":"none"}`) + "." + payload + ".", ParseOptions{AllowDuplicateMembers: true}, true},
+		"non-canonical":         {header + "." + "cGF5bG9hZB" + ".", ParseOptions{}, false},
+		"non-canonical allowed": {header + "." + "cGF5bG9hZB" + ".", ParseOptions{AllowNonCanonicalEncoding: true}, true},
+		"invalid header":        {enc(`[]`) + "." + payload + ".", ParseOptions{}, false},
+		"huge default":          {header + "." + strings.Repeat("A", DefaultMaxTokenSize) + ".", ParseOptions{}, false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			j, err := ParseCompactWithOptions(test.compact, test.opts)
+			if test.valid {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(j.Payload()) != "payload" || j.Header().Algorithm != ALG_NONE {
+					t.Errorf("unexpected JWS: %#v", j)
+				}
+			} else if !errors.Is(err, ErrInvalidCompactJWS) {
+				t.Errorf("expected ErrInvalidCompactJWS but got %v", err)
+			}
+		})
+	}
+
+	t.Run("header errors", func(t *testing.T) {
+		for _, h := range []string{`{"alg":"none","alg":"HS256"}`, `[]`} {
+			_, err := ParseCompact(enc(h) + "." + payload + ".")
+			if !errors.Is(err, ErrInvalidCompactJWS) || !errors.Is(err, ErrInvalidHeader) {
+				t.Errorf("%s: expected ErrInvalidCompactJWS and ErrInvalidHeader but got %v", h, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package jwt
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Audiences implements the "aud" claim, which may be either a single string
+// or an array of strings as defined in RFC 7519 section 4.1.3
+// (https://datatracker.ietf.org/doc/html/rfc7519#section-4.1.3). Audiences
+// are always marshaled as an array. Use SingleAudience or the Builder's
+// SingleAudienceAsString to marshal a single audience as a bare string.
+type Audiences []string
+
+func (a Audiences) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]string(a))
+}
+
+func (a *Audiences) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	switch val := v.(type) {
+	case nil:
+		*a = nil
+	case string:
+		*a = Audiences{val}
+	case []any:
+		aud := make(Audiences, len(val))
+		for i, item := range val {
+			s, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("invalid aud: contains non-string element: %v", item)
+			}
+			aud[i] = s
+		}
+		*a = aud
+	default:
+		return fmt.Errorf("invalid aud: not a string or array of strings: %v", v)
+	}
+
+	return nil
+}
+
+// SingleAudience implements an "aud" claim containing exactly one audience
+// which is marshaled as a bare JSON string as permitted by RFC 7519 section
+// 4.1.3. Unmarshaling accepts a string or an array containing exactly one
+// string.
+type SingleAudience string
+
+func (a *SingleAudience) UnmarshalJSON(data []byte) error {
+	var aud Audiences
+	if err := aud.UnmarshalJSON(data); err != nil {
+		return err
+	}
+
+	switch len(aud) {
+	case 0:
+		*a = ""
+	case 1:
+		*a = SingleAudience(aud[0])
+	default:
+		return fmt.Errorf("invalid aud: expected a single audience but got %d", len(aud))
+	}
+
+	return nil
+}
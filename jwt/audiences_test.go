@@ -0,0 +1,80 @@
+package jwt
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestAudiences_JSON(t *testing.T) {
+	t.Run("unmarshal", func(t *testing.T) {
+		tests := map[string]Audiences{
+			`"a"`:       {"a"},
+			`["a","b"]`: {"a", "b"},
+			`null`:      nil,
+		}
+
+		for data, want := range tests {
+			var got Audiences
+			if err := json.Unmarshal([]byte(data), &got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := deep.Equal(want, got); diff != nil {
+				t.Errorf("%s: %v", data, diff)
+			}
+		}
+
+		for _, data := range []string{`17`, `["a",17]`} {
+			var got Audiences
+			if err := json.Unmarshal([]byte(data), &got); err == nil {
+				t.Errorf("%s: expected error but got nil", data)
+			}
+		}
+	})
+
+	t.Run("marshal", func(t *testing.T) {
+		data, err := json.Marshal(Audiences{"a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != `["a"]` {
+			t.Errorf("unexpected JSON: %s", data)
+		}
+
+		data, err = json.Marshal(Audiences{"a", "b"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != `["a","b"]` {
+			t.Errorf("unexpected JSON: %s", data)
+		}
+	})
+}
+
+func TestSingleAudience_JSON(t *testing.T) {
+	data, err := json.Marshal(struct {
+		Audience SingleAudience `json:"aud"`
+	}{"a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"aud":"a"}` {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+
+	for data, want := range map[string]SingleAudience{`"a"`: "a", `["a"]`: "a", `null`: ""} {
+		var got SingleAudience
+		if err := json.Unmarshal([]byte(data), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%s: expected %q but got %q", data, want, got)
+		}
+	}
+
+	var got SingleAudience
+	if err := json.Unmarshal([]byte(`["a","b"]`), &got); err == nil {
+		t.Error("expected error but got nil")
+	}
+}
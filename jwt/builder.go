@@ -0,0 +1,172 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/halimath/jose/internal/encoding"
+	"github.com/halimath/jose/jws"
+)
+
+// IDGenerator defines the interface for types that generate values for the
+// "jti" claim.
+type IDGenerator interface {
+	GenerateID() (string, error)
+}
+
+// IDGeneratorFunc is a convenience type that wraps a single function as an IDGenerator.
+type IDGeneratorFunc func() (string, error)
+
+func (f IDGeneratorFunc) GenerateID() (string, error) {
+	return f()
+}
+
+// RandomIDGenerator is an IDGenerator that generates IDs from 16 random
+// bytes encoded using base64url.
+var RandomIDGenerator IDGenerator = IDGeneratorFunc(func() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encoding.Encode(b), nil
+})
+
+// Builder creates signed tokens applying a common set of claims and header
+// parameters. The zero value of all fields but Signer is usable. A Builder
+// may be used concurrently as long as its fields are not modified.
+type Builder struct {
+	// Signer signs the tokens. It must not be nil.
+	Signer jws.Signer
+
+	// TTL defines the lifetime of the tokens. If non-zero, "exp" is set to
+	// "iat" plus TTL and "nbf" is set to "iat".
+	TTL time.Duration
+
+	// Issuer is used as the "iss" claim unless the claims contain one.
+	Issuer string
+
+	// Audience is used as the "aud" claim unless the claims contain one.
+	Audience Audiences
+
+	// SingleAudienceAsString makes Sign emit an "aud" claim containing
+	// exactly one audience as a bare string instead of an array with one
+	// element, as permitted by RFC 7519 section 4.1.3.
+	SingleAudienceAsString bool
+
+	// KeyID is used as the header's "kid" parameter.
+	KeyID string
+
+	// Type is used as the header's "typ" parameter. If empty, HeaderType is
+	// used.
+	Type string
+
+	// ContentType is used as the header's "cty" parameter.
+	ContentType string
+
+	// Clock provides the time used for "iat", "nbf" and "exp". If nil,
+	// SystemClock is used.
+	Clock Clock
+
+	// IDGenerator generates the "jti" claim unless the claims contain one.
+	// If nil, RandomIDGenerator is used.
+	IDGenerator IDGenerator
+}
+
+// SignOptions defines per-token options for Builder.SignWithOptions. The
+// zero value applies no overrides.
+type SignOptions struct {
+	// Audience, if non-empty, is used as the "aud" claim, replacing both the
+	// Builder's Audience and an "aud" contained in the claims.
+	Audience Audiences
+}
+
+// Sign creates a signed token from claims, which must marshal to a JSON
+// object, and the defaults configured for b. Claims contained in claims take
+// precedence over the defaults. Passing nil creates a token with only the
+// defaults.
+func (b *Builder) Sign(claims any) (*Token, error) {
+	return b.SignWithOptions(claims, SignOptions{})
+}
+
+// SignWithOptions works like Sign but applies the overrides given in opts.
+func (b *Builder) SignWithOptions(claims any, opts SignOptions) (*Token, error) {
+	c := Claims{}
+	if claims != nil {
+		data, err := json.Marshal(claims)
+		if err != nil {
+			return nil, err
+		}
+		// Decode numbers as json.Number so integers beyond 2^53 are
+		// re-encoded unchanged.
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&c); err != nil {
+			return nil, fmt.Errorf("claims do not marshal to a JSON object: %v", err)
+		}
+	}
+
+	clock := b.Clock
+	if clock == nil {
+		clock = SystemClock
+	}
+	now := clock.Now().Truncate(time.Second)
+
+	setDefault(c, ClaimIssuedAt, NewNumericDate(now))
+	if b.TTL != 0 {
+		setDefault(c, ClaimNotBefore, NewNumericDate(now))
+		setDefault(c, ClaimExpirationTime, NewNumericDate(now.Add(b.TTL)))
+	}
+
+	if b.Issuer != "" {
+		setDefault(c, ClaimIssuer, b.Issuer)
+	}
+
+	if len(opts.Audience) > 0 {
+		c[ClaimAudience] = opts.Audience
+	} else if len(b.Audience) > 0 {
+		setDefault(c, ClaimAudience, b.Audience)
+	}
+
+	if b.SingleAudienceAsString {
+		if aud, err := c.GetStringSlice(ClaimAudience); err == nil && len(aud) == 1 {
+			c[ClaimAudience] = aud[0]
+		}
+	}
+
+	if !c.Has(ClaimID) {
+		gen := b.IDGenerator
+		if gen == nil {
+			gen = RandomIDGenerator
+		}
+		id, err := gen.GenerateID()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate jti: %v", err)
+		}
+		c[ClaimID] = id
+	}
+
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	typ := b.Type
+	if typ == "" {
+		typ = HeaderType
+	}
+
+	return signSerialized(b.Signer, payload, jws.Header{
+		Type:        typ,
+		KeyID:       b.KeyID,
+		ContentType: b.ContentType,
+	})
+}
+
+func setDefault(claims Claims, claim string, value any) {
+	if !claims.Has(claim) {
+		claims[claim] = value
+	}
+}
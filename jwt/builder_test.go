@@ -0,0 +1,172 @@
+package jwt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/halimath/jose/jws"
+)
+
+func TestBuilder(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	sig := jws.HS256([]byte("secret"))
+
+	b := Builder{
+		Signer:      sig,
+		TTL:         time.Hour,
+		Issuer:      "https://issuer.example.com",
+		Audience:    Audiences{"api"},
+		KeyID:       "key-1",
+		ContentType: "example",
+		Clock:       FixedClock(now.Add(500 * time.Millisecond)),
+		IDGenerator: IDGeneratorFunc(func() (string, error) { return "id-1", nil }),
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		token, err := b.Sign(map[string]any{"sub": "john.doe"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := deep.Equal(jws.Header{
+			Algorithm:   jws.ALG_HS256,
+			Type:        HeaderType,
+			KeyID:       "key-1",
+			ContentType: "example",
+		}, token.Header()); diff != nil {
+			t.Error(diff)
+		}
+
+		if diff := deep.Equal(StandardClaims{
+			Subject:        "john.doe",
+			Issuer:         "https://issuer.example.com",
+			Audience:       Audiences{"api"},
+			IssuedAt:       NewNumericDate(time.Unix(now.Unix(), 0)),
+			NotBefore:      NewNumericDate(time.Unix(now.Unix(), 0)),
+			ExpirationTime: NewNumericDate(time.Unix(now.Add(time.Hour).Unix(), 0)),
+			ID:             "id-1",
+		}, token.StandardClaims()); diff != nil {
+			t.Error(diff)
+		}
+
+		if err := token.Verify(
+			Signature(sig),
+			ExpirationTimeWithOptions(TimeOptions{Clock: b.Clock}),
+			NotBeforeWithOptions(TimeOptions{Clock: b.Clock}),
+		); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("claims take precedence", func(t *testing.T) {
+		token, err := b.Sign(StandardClaims{
+			Issuer: "other",
+			ID:     "id-2",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		claims := token.StandardClaims()
+		if claims.Issuer != "other" || claims.ID != "id-2" {
+			t.Errorf("unexpected claims: %#v", claims)
+		}
+	})
+
+	t.Run("reproducible", func(t *testing.T) {
+		t1, err := b.Sign(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t2, err := b.Sign(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if t1.Compact() != t2.Compact() {
+			t.Errorf("expected identical tokens but got\n%s\n%s", t1.Compact(), t2.Compact())
+		}
+	})
+
+	t.Run("random jti", func(t *testing.T) {
+		b := Builder{Signer: sig}
+
+		t1, err := b.Sign(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t2, err := b.Sign(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		id1, id2 := t1.StandardClaims().ID, t2.StandardClaims().ID
+		if id1 == "" || id1 == id2 {
+			t.Errorf("expected distinct random IDs but got %q and %q", id1, id2)
+		}
+
+		if t1.StandardClaims().ExpirationTime != nil {
+			t.Error("expected no exp without TTL")
+		}
+	})
+
+	t.Run("id generator error", func(t *testing.T) {
+		b := Builder{
+			Signer:      sig,
+			IDGenerator: IDGeneratorFunc(func() (string, error) { return "", errors.New("failed") }),
+		}
+		if _, err := b.Sign(nil); err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+
+	t.Run("large integers", func(t *testing.T) {
+		token, err := b.Sign(map[string]any{"account_id": int64(9007199254740993)})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(string(token.Payload()), `"account_id":9007199254740993`) {
+			t.Errorf("expected integer to be preserved but got %s", token.Payload())
+		}
+	})
+
+	t.Run("single audience as string", func(t *testing.T) {
+		b := b
+		b.SingleAudienceAsString = true
+
+		token, err := b.Sign(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(token.Payload()), `"aud":"api"`) {
+			t.Errorf("expected single audience as string but got %s", token.Payload())
+		}
+
+		token, err = b.Sign(StandardClaims{Audience: Audiences{"a", "b"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(token.Payload()), `"aud":["a","b"]`) {
+			t.Errorf("expected multiple audiences as array but got %s", token.Payload())
+		}
+	})
+
+	t.Run("audience override", func(t *testing.T) {
+		token, err := b.SignWithOptions(StandardClaims{Audience: Audiences{"claims"}}, SignOptions{Audience: Audiences{"override"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := deep.Equal(Audiences{"override"}, token.StandardClaims().Audience); diff != nil {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("invalid claims", func(t *testing.T) {
+		if _, err := b.Sign([]string{"foo"}); err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+}
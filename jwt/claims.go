@@ -135,20 +135,44 @@ func (claims Claims) GetInt(claim string) (int64, error) {
 	return 0, fmt.Errorf("claim value for %s is not of type number: %v", claim, v)
 }
 
-// GetTime returns the named claim's value as a time.Time.
-// If the claim is not found, it returns the zero time.
-// If the claim is not a numeric value, it returns an error.
+// GetTime returns the named claim's value as a time.Time preserving
+// fractional seconds. If the claim is not found or its value is 0, it
+// returns the zero time. If the claim is not a numeric value, it returns an
+// error.
 func (claims Claims) GetTime(claim string) (time.Time, error) {
-	v, err := claims.GetInt(claim)
-	if err != nil {
-		return time.Time{}, err
+	v, ok := claims[claim]
+	if !ok {
+		return time.Time{}, nil
 	}
 
-	if v == 0 {
-		return time.Time{}, err
+	var t time.Time
+
+	switch val := v.(type) {
+	case int64:
+		t = time.Unix(val, 0)
+	case float64:
+		t = floatToTime(val)
+	case json.Number:
+		var err error
+		t, err = parseNumericDate(string(val))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("claim value for %s is not of type number: %v", claim, v)
+		}
+	case NumericDate:
+		t = val.Time
+	case *NumericDate:
+		if val != nil {
+			t = val.Time
+		}
+	default:
+		return time.Time{}, fmt.Errorf("claim value for %s is not of type number: %v", claim, v)
 	}
 
-	return time.Unix(v, 0), nil
+	if t.Equal(time.Unix(0, 0)) {
+		return time.Time{}, nil
+	}
+
+	return t, nil
 }
 
 // GetStringSlice returns the named claim's value from claims as a slice of strings.
@@ -167,6 +191,8 @@ func (claims Claims) GetStringSlice(claim string) ([]string, error) {
 		return []string{val}, nil
 	case []string:
 		return val, nil
+	case Audiences:
+		return val, nil
 	case []any:
 		result := make([]string, len(val))
 		for i, item := range val {
@@ -215,7 +241,7 @@ type StandardClaims struct {
 	// interpretation of audience values is generally application specific.
 	// Use of this claim is OPTIONAL.
 	// (https://datatracker.ietf.org/doc/html/rfc7519#section-4.1.3)
-	Audience []string `json:"aud,omitempty"`
+	Audience Audiences `json:"aud,omitempty"`
 
 	// The "exp" (expiration time) claim identifies the expiration time on
 	// or after which the JWT MUST NOT be accepted for processing.  The
@@ -225,7 +251,7 @@ type StandardClaims struct {
 	// a few minutes, to account for clock skew.  Its value MUST be a number
 	// containing a NumericDate value.  Use of this claim is OPTIONAL.
 	// (https://datatracker.ietf.org/doc/html/rfc7519#section-4.1.4)
-	ExpirationTime int64 `json:"exp,omitempty"`
+	ExpirationTime *NumericDate `json:"exp,omitempty"`
 
 	// The "nbf" (not before) claim identifies the time before which the JWT
 	// MUST NOT be accepted for processing.  The processing of the "nbf"
@@ -235,14 +261,14 @@ type StandardClaims struct {
 	// account for clock skew.  Its value MUST be a number containing a
 	// NumericDate value.  Use of this claim is OPTIONAL.
 	// (https://datatracker.ietf.org/doc/html/rfc7519#section-4.1.5)
-	NotBefore int64 `json:"nbf,omitempty"`
+	NotBefore *NumericDate `json:"nbf,omitempty"`
 
 	// The "iat" (issued at) claim identifies the time at which the JWT was
 	// issued.  This claim can be used to determine the age of the JWT.  Its
 	// value MUST be a number containing a NumericDate value.  Use of this
 	// claim is OPTIONAL.
 	// (https://datatracker.ietf.org/doc/html/rfc7519#section-4.1.6)
-	IssuedAt int64 `json:"iat,omitempty"`
+	IssuedAt *NumericDate `json:"iat,omitempty"`
 
 	// The "jti" (JWT ID) claim provides a unique identifier for the JWT.
 	// The identifier value MUST be assigned in a manner that ensures that
@@ -256,35 +282,112 @@ type StandardClaims struct {
 	ID string `json:"jti,omitempty"`
 }
 
-// GetExpirationTime returns the contained expiration time as a time.Time value.
+// GetExpirationTime returns the contained expiration time as a time.Time value
+// or the zero time if no expiration time is set.
 func (s *StandardClaims) GetExpirationTime() time.Time {
-	return time.Unix(s.ExpirationTime, 0)
+	return s.ExpirationTime.time()
 }
 
 // SetExpirationTime populates the expiration time from the given time.Time value.
 func (s *StandardClaims) SetExpirationTime(exp time.Time) *StandardClaims {
+	s.ExpirationTime = NewNumericDate(exp)
+	return s
+}
+
+// GetNotBefore returns the contained not before time as a time.Time value
+// or the zero time if no not before time is set.
+func (s *StandardClaims) GetNotBefore() time.Time {
+	return s.NotBefore.time()
+}
+
+// SetNotBefore populates the not before time from the given time.Time value.
+func (s *StandardClaims) SetNotBefore(nbf time.Time) *StandardClaims {
+	s.NotBefore = NewNumericDate(nbf)
+	return s
+}
+
+// GetIssuedAt returns the contained issued at time as a time.Time value
+// or the zero time if no issued at time is set.
+func (s *StandardClaims) GetIssuedAt() time.Time {
+	return s.IssuedAt.time()
+}
+
+// SetIssuedAt populates the issued at time from the given time.Time value.
+func (s *StandardClaims) SetIssuedAt(iat time.Time) *StandardClaims {
+	s.IssuedAt = NewNumericDate(iat)
+	return s
+}
+
+// LegacyStandardClaims mirrors the layout of StandardClaims used by earlier
+// versions of this package, which model "exp", "nbf" and "iat" as Unix
+// timestamps and "aud" as a []string. It exists to ease migrating code that
+// accesses these fields directly: replace StandardClaims with
+// LegacyStandardClaims to keep such code compiling unchanged. Tokens
+// carrying a single string "aud" claim are accepted by
+// Token.UnmarshalClaims, ClaimsAs and DecodeAs.
+//
+// Deprecated: Use StandardClaims. LegacyStandardClaims drops fractional
+// seconds and will be removed in a future version.
+type LegacyStandardClaims struct {
+	Subject        string   `json:"sub,omitempty"`
+	Issuer         string   `json:"iss,omitempty"`
+	Audience       []string `json:"aud,omitempty"`
+	ExpirationTime int64    `json:"exp,omitempty"`
+	NotBefore      int64    `json:"nbf,omitempty"`
+	IssuedAt       int64    `json:"iat,omitempty"`
+	ID             string   `json:"jti,omitempty"`
+}
+
+// GetExpirationTime returns the contained expiration time as a time.Time value.
+func (s *LegacyStandardClaims) GetExpirationTime() time.Time {
+	return time.Unix(s.ExpirationTime, 0)
+}
+
+// SetExpirationTime populates the expiration time from the given time.Time value.
+func (s *LegacyStandardClaims) SetExpirationTime(exp time.Time) *LegacyStandardClaims {
 	s.ExpirationTime = exp.Unix()
 	return s
 }
 
 // GetNotBefore returns the contained not before time as a time.Time value.
-func (s *StandardClaims) GetNotBefore() time.Time {
+func (s *LegacyStandardClaims) GetNotBefore() time.Time {
 	return time.Unix(s.NotBefore, 0)
 }
 
 // SetNotBefore populates the not before time from the given time.Time value.
-func (s *StandardClaims) SetNotBefore(nbf time.Time) *StandardClaims {
+func (s *LegacyStandardClaims) SetNotBefore(nbf time.Time) *LegacyStandardClaims {
 	s.NotBefore = nbf.Unix()
 	return s
 }
 
 // GetIssuedAt returns the contained issued at time as a time.Time value.
-func (s *StandardClaims) GetIssuedAt() time.Time {
+func (s *LegacyStandardClaims) GetIssuedAt() time.Time {
 	return time.Unix(s.IssuedAt, 0)
 }
 
 // SetIssuedAt populates the issued at time from the given time.Time value.
-func (s *StandardClaims) SetIssuedAt(iat time.Time) *StandardClaims {
+func (s *LegacyStandardClaims) SetIssuedAt(iat time.Time) *LegacyStandardClaims {
 	s.IssuedAt = iat.Unix()
 	return s
 }
+
+// StandardClaims converts s to a StandardClaims value. Zero timestamps are
+// converted to nil.
+func (s LegacyStandardClaims) StandardClaims() StandardClaims {
+	return StandardClaims{
+		Subject:        s.Subject,
+		Issuer:         s.Issuer,
+		Audience:       Audiences(s.Audience),
+		ExpirationTime: legacyNumericDate(s.ExpirationTime),
+		NotBefore:      legacyNumericDate(s.NotBefore),
+		IssuedAt:       legacyNumericDate(s.IssuedAt),
+		ID:             s.ID,
+	}
+}
+
+func legacyNumericDate(sec int64) *NumericDate {
+	if sec == 0 {
+		return nil
+	}
+	return NewNumericDate(time.Unix(sec, 0))
+}
@@ -0,0 +1,50 @@
+package jwt
+
+import "time"
+
+// Clock defines the interface for types that provide the current time to
+// time-based verifiers.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is a convenience type that wraps a single function as a Clock.
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock is a Clock that reports the system's current time.
+var SystemClock Clock = ClockFunc(time.Now)
+
+// FixedClock returns a Clock that always reports t. This is useful for tests
+// and to verify historical tokens.
+func FixedClock(t time.Time) Clock {
+	return ClockFunc(func() time.Time {
+		return t
+	})
+}
+
+// TimeOptions defines the options for time-based verifiers. The zero value
+// uses the SystemClock and no leeway.
+type TimeOptions struct {
+	// Clock provides the time to verify against. If nil, SystemClock is used.
+	Clock Clock
+
+	// Leeway compensates for differences in server time. All time-based
+	// verifiers apply the leeway in favor of accepting the token.
+	Leeway time.Duration
+
+	// Optional makes the verifier accept tokens that do not carry the claim
+	// being verified. If the claim is present it is enforced. Use Required
+	// to demand the presence of a claim.
+	Optional bool
+}
+
+func (o TimeOptions) now() time.Time {
+	if o.Clock == nil {
+		return SystemClock.Now()
+	}
+	return o.Clock.Now()
+}
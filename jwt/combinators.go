@@ -0,0 +1,100 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AllOf returns a verifier that accepts a token iff all of verifiers accept
+// it. The verifiers are applied in order and the first error is returned
+// annotated with the failing verifier's position.
+func AllOf(verifiers ...Verifier) Verifier {
+	return Named("all of", VerifierFunc(func(token *Token) error {
+		for i, v := range verifiers {
+			if err := v.Verify(token); err != nil {
+				return fmt.Errorf("verifier %d of %d failed: %w", i+1, len(verifiers), err)
+			}
+		}
+		return nil
+	}))
+}
+
+// AnyOf returns a verifier that accepts a token iff at least one of
+// verifiers accepts it. If all verifiers reject the token, the returned error
+// lists each alternative's error. Each of these errors is reachable via
+// errors.Is and errors.As.
+func AnyOf(verifiers ...Verifier) Verifier {
+	return Named("any of", VerifierFunc(func(token *Token) error {
+		errs := make([]error, 0, len(verifiers))
+		for _, v := range verifiers {
+			err := v.Verify(token)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err)
+		}
+		return anyOfError(errs)
+	}))
+}
+
+// anyOfError is the error returned from AnyOf.
+type anyOfError []error
+
+func (e anyOfError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = fmt.Sprintf("alternative %d: %s", i+1, err)
+	}
+	return fmt.Sprintf("none of %d alternatives accepted the token: %s", len(e), strings.Join(msgs, "; "))
+}
+
+func (e anyOfError) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e anyOfError) As(target any) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Not returns a verifier that accepts a token iff verifier rejects it.
+func Not(verifier Verifier) Verifier {
+	return Named("not", VerifierFunc(func(token *Token) error {
+		if err := verifier.Verify(token); err != nil {
+			return nil
+		}
+		return errors.New("negated verifier accepted the token")
+	}))
+}
+
+// When returns a verifier that applies verifier only if condition returns
+// true for the token. Otherwise the token is accepted.
+func When(condition func(token *Token) bool, verifier Verifier) Verifier {
+	return Named("when", VerifierFunc(func(token *Token) error {
+		if !condition(token) {
+			return nil
+		}
+		if err := verifier.Verify(token); err != nil {
+			return fmt.Errorf("conditional verifier failed: %w", err)
+		}
+		return nil
+	}))
+}
+
+// HasClaim returns a condition for use with When that is satisfied if the
+// token carries the named claim.
+func HasClaim(claim string) func(token *Token) bool {
+	return func(token *Token) bool {
+		return token.claimsWith(claim).Has(claim)
+	}
+}
@@ -0,0 +1,80 @@
+package jwt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnyOfAllOf(t *testing.T) {
+	v := AnyOf(
+		AllOf(Issuer("a"), Audience("x")),
+		AllOf(Issuer("b"), Audience("y")),
+	)
+
+	t.Run("first branch", func(t *testing.T) {
+		if err := v.Verify(&Token{claims: Claims{ClaimIssuer: "a", ClaimAudience: "x"}}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("second branch", func(t *testing.T) {
+		if err := v.Verify(&Token{claims: Claims{ClaimIssuer: "b", ClaimAudience: "y"}}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("no branch", func(t *testing.T) {
+		err := v.Verify(&Token{claims: Claims{ClaimIssuer: "a", ClaimAudience: "y"}})
+		if err == nil {
+			t.Fatal("expected error but got nil")
+		}
+
+		if !errors.Is(err, ErrAudienceMismatch) || !errors.Is(err, ErrIssuerMismatch) {
+			t.Errorf("expected audience and issuer mismatch but got %v", err)
+		}
+
+		msg := err.Error()
+		for _, want := range []string{"alternative 1: verifier 2 of 2 failed", "alternative 2: verifier 1 of 2 failed"} {
+			if !strings.Contains(msg, want) {
+				t.Errorf("expected %q to contain %q", msg, want)
+			}
+		}
+	})
+}
+
+func TestNot(t *testing.T) {
+	v := Not(Issuer("a"))
+
+	if err := v.Verify(&Token{claims: Claims{ClaimIssuer: "b"}}); err != nil {
+		t.Error(err)
+	}
+
+	if err := v.Verify(&Token{claims: Claims{ClaimIssuer: "a"}}); err == nil {
+		t.Error("expected error but got nil")
+	}
+}
+
+func TestWhen(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	v := When(HasClaim(ClaimIssuedAt), MaxAgeWithOptions(time.Minute, TimeOptions{Clock: FixedClock(now)}))
+
+	t.Run("no iat", func(t *testing.T) {
+		if err := v.Verify(&Token{claims: Claims{}}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("recent iat", func(t *testing.T) {
+		if err := v.Verify(&Token{claims: Claims{ClaimIssuedAt: now.Unix()}}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("old iat", func(t *testing.T) {
+		if err := v.Verify(&Token{claims: Claims{ClaimIssuedAt: now.Add(-time.Hour).Unix()}}); !errors.Is(err, ErrTokenExpired{}) {
+			t.Errorf("expected ErrTokenExpired but got %v", err)
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package jwt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/halimath/jose/internal/encoding"
+	"github.com/halimath/jose/internal/strictjson"
+	"github.com/halimath/jose/jwe"
+	"github.com/halimath/jose/jws"
+)
+
+// DecrypterResolver defines the interface for types that resolve the
+// jwe.KeyDecrypter used to decrypt an encrypted token.
+type DecrypterResolver interface {
+	// ResolveDecrypter returns the decrypter to use for a JWE with the given
+	// header or a non-nil error if no decrypter is available.
+	ResolveDecrypter(header jwe.Header) (jwe.KeyDecrypter, error)
+}
+
+// DecrypterResolverFunc is a convenience type that wraps a single function as a DecrypterResolver.
+type DecrypterResolverFunc func(header jwe.Header) (jwe.KeyDecrypter, error)
+
+func (f DecrypterResolverFunc) ResolveDecrypter(header jwe.Header) (jwe.KeyDecrypter, error) {
+	return f(header)
+}
+
+// StaticDecrypter returns a DecrypterResolver that always resolves decrypter.
+// Use jwe.KeySetDecrypter to resolve keys from a jwk.Set.
+func StaticDecrypter(decrypter jwe.KeyDecrypter) DecrypterResolver {
+	return DecrypterResolverFunc(func(jwe.Header) (jwe.KeyDecrypter, error) {
+		return decrypter, nil
+	})
+}
+
+// EncryptionHeader returns the header of the JWE t has been decrypted from
+// and true. If t has not been decrypted, the zero header and false are
+// returned.
+func (t *Token) EncryptionHeader() (jwe.Header, bool) {
+	if t.encryptionHeader == nil {
+		return jwe.Header{}, false
+	}
+	return *t.encryptionHeader, true
+}
+
+// Encrypt encrypts the signed token t using encrypter and the content
+// encryption algorithm enc. It returns a JWE containing a nested JWT as
+// defined in RFC 7519 section 5.2
+// (https://datatracker.ietf.org/doc/html/rfc7519#section-5.2), i.e. the
+// JWE's "cty" header is set to "JWT".
+func Encrypt(t *Token, encrypter jwe.KeyEncrypter, enc jwe.ContentEncryptionAlgorithm) (*jwe.JWE, error) {
+	return jwe.Encrypt(encrypter, enc, []byte(t.Compact()), jwe.Header{
+		ContentType: HeaderType,
+	})
+}
+
+// DecodeNested decodes the given compact token string. If compact contains
+// a JWE (five parts) it is decrypted using the decrypter obtained from
+// resolver and the plaintext is decoded as the nested token. Only JWEs
+// carrying a "cty" header of "JWT" are accepted. If compact contains a JWS,
+// DecodeNested works like Decode.
+//
+// As with Decode, the returned token is not verified. Use Verify to
+// perform the verification of the inner token.
+func DecodeNested(compact string, resolver DecrypterResolver) (*Token, error) {
+	return DecodeNestedWithOptions(compact, resolver, DecodeOptions{})
+}
+
+// DecodeNestedWithOptions works like DecodeNested but applies opts. The
+// size limits and the duplicate member check of opts.ParseOptions apply to
+// both the JWE and the nested token; MaxTokenSize also limits the size of
+// the decrypted (and decompressed) plaintext. The accepted types apply to
+// the nested token as well as to the JWE's "typ" header if present.
+func DecodeNestedWithOptions(compact string, resolver DecrypterResolver, opts DecodeOptions) (*Token, error) {
+	if strings.Count(compact, ".") != 4 {
+		return DecodeWithOptions(compact, opts)
+	}
+
+	maxTokenSize := opts.ParseOptions.MaxTokenSize
+	if maxTokenSize <= 0 {
+		maxTokenSize = jws.DefaultMaxTokenSize
+	}
+	if len(compact) > maxTokenSize {
+		return nil, fmt.Errorf("%w: encrypted token exceeds maximum size of %d bytes", ErrInvalidToken, maxTokenSize)
+	}
+
+	if err := checkEncryptionHeader(compact[:strings.IndexByte(compact, '.')], opts.ParseOptions); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	encrypted, err := jwe.ParseCompact(compact)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	header := encrypted.Header()
+	if !typesEqual(header.ContentType, HeaderType) {
+		return nil, fmt.Errorf("%w: encrypted token does not contain a nested JWT: found cty %q", ErrInvalidToken, header.ContentType)
+	}
+
+	if header.Type != "" && !opts.acceptsType(header.Type) {
+		return nil, fmt.Errorf("%w: encrypted token type not accepted: found %q", ErrInvalidToken, header.Type)
+	}
+
+	decrypter, err := resolver.ResolveDecrypter(header)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	plaintext, err := encrypted.DecryptWithOptions(decrypter, jwe.DecryptOptions{MaxDecompressedSize: maxTokenSize})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	tok, err := DecodeWithOptions(string(plaintext), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	tok.encryptionHeader = &header
+
+	return tok, nil
+}
+
+// checkEncryptionHeader applies the header size limit and the duplicate
+// member check of opts to the encoded JWE header.
+func checkEncryptionHeader(encoded string, opts jws.ParseOptions) error {
+	maxHeaderSize := opts.MaxHeaderSize
+	if maxHeaderSize <= 0 {
+		maxHeaderSize = jws.DefaultMaxHeaderSize
+	}
+	if encoding.DecodedLen(len(encoded)) > maxHeaderSize {
+		return fmt.Errorf("header exceeds maximum size of %d bytes", maxHeaderSize)
+	}
+
+	if opts.AllowDuplicateMembers {
+		return nil
+	}
+
+	data, err := encoding.Decode(encoded)
+	if err != nil {
+		return fmt.Errorf("invalid header: %v", err)
+	}
+
+	if err := strictjson.CheckDuplicateMembers(data); err != nil {
+		return fmt.Errorf("invalid header: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package jwt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/halimath/jose/jwe"
+	"github.com/halimath/jose/jwk"
+	"github.com/halimath/jose/jws"
+)
+
+func TestEncryptDecodeNested(t *testing.T) {
+	sig := jws.HS256([]byte("secret"))
+	kek := []byte("0123456789abcdef")
+
+	km, err := jwe.A128KW(kek)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := Sign(sig, StandardClaims{
+		Subject: "john.doe",
+		Issuer:  "oauth-server",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := Encrypt(token, km, jwe.ENC_A128CBC_HS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if encrypted.Header().ContentType != HeaderType {
+		t.Errorf("unexpected cty: %q", encrypted.Header().ContentType)
+	}
+
+	compact := encrypted.Compact()
+
+	t.Run("static decrypter", func(t *testing.T) {
+		decoded, err := DecodeNested(compact, StaticDecrypter(km))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := decoded.Verify(Signature(sig), Issuer("oauth-server")); err != nil {
+			t.Error(err)
+		}
+
+		if diff := deep.Equal(token.StandardClaims(), decoded.StandardClaims()); diff != nil {
+			t.Error(diff)
+		}
+
+		h, ok := decoded.EncryptionHeader()
+		if !ok {
+			t.Error("expected encryption header")
+		}
+		if h.Algorithm != jwe.ALG_A128KW {
+			t.Errorf("unexpected alg: %s", h.Algorithm)
+		}
+	})
+
+	t.Run("key set decrypter", func(t *testing.T) {
+		set := jwk.Set{
+			&jwk.SymmetricKey{Bytes: kek},
+		}
+
+		decoded, err := DecodeNested(compact, StaticDecrypter(jwe.KeySetDecrypter(set)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := decoded.Verify(Signature(sig)); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		other, err := jwe.A128KW([]byte("fedcba9876543210"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := DecodeNested(compact, StaticDecrypter(other)); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("expected ErrInvalidToken but got %v", err)
+		}
+	})
+
+	t.Run("resolver error", func(t *testing.T) {
+		resolver := DecrypterResolverFunc(func(jwe.Header) (jwe.KeyDecrypter, error) {
+			return nil, errors.New("no key")
+		})
+
+		if _, err := DecodeNested(compact, resolver); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("expected ErrInvalidToken but got %v", err)
+		}
+	})
+
+	t.Run("decode rejects JWE", func(t *testing.T) {
+		if _, err := Decode(compact); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("expected ErrInvalidToken but got %v", err)
+		}
+	})
+
+	t.Run("plain JWS", func(t *testing.T) {
+		decoded, err := DecodeNested(token.Compact(), StaticDecrypter(km))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := decoded.EncryptionHeader(); ok {
+			t.Error("expected no encryption header")
+		}
+	})
+}
+
+func TestDecodeNested_missingContentType(t *testing.T) {
+	km, err := jwe.A128KW([]byte("0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := jwe.Encrypt(km, jwe.ENC_A128GCM, []byte(`{"sub":"john.doe"}`), jwe.Header{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := DecodeNested(encrypted.Compact(), StaticDecrypter(km)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken but got %v", err)
+	}
+}
+
+func TestDecodeNestedWithOptions(t *testing.T) {
+	kek := []byte("0123456789abcdef")
+	km, err := jwe.A128KW(kek)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypt := func(payload string, typ string) string {
+		token, err := signSerialized(jws.None(), []byte(payload), jws.Header{Type: typ})
+		if err != nil {
+			t.Fatal(err)
+		}
+		encrypted, err := Encrypt(token, km, jwe.ENC_A128GCM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return encrypted.Compact()
+	}
+
+	accessTokens := DecodeOptions{AcceptedTypes: []string{"at+jwt"}}
+
+	tests := map[string]struct {
+		compact string
+		opts    DecodeOptions
+		valid   bool
+	}{
+		"default":               {encrypt(`{"sub":"a"}`, "JWT"), DecodeOptions{}, true},
+		"type not accepted":     {encrypt(`{"sub":"a"}`, "at+jwt"), DecodeOptions{}, false},
+		"type accepted":         {encrypt(`{"sub":"a"}`, "at+jwt"), accessTokens, true},
+		"duplicate claims":      {encrypt(`{"sub":"a","sub":"b"}`, "JWT"), DecodeOptions{}, false},
+		"duplicates allowed":    {encrypt(`{"sub":"a","sub":"b"}`, "JWT"), DecodeOptions{ParseOptions: jws.ParseOptions{AllowDuplicateMembers: true}}, true},
+		"token too large":       {encrypt(`{"sub":"a"}`, "JWT"), DecodeOptions{ParseOptions: jws.ParseOptions{MaxTokenSize: 64}}, false},
+		"header too large":      {encrypt(`{"sub":"a"}`, "JWT"), DecodeOptions{ParseOptions: jws.ParseOptions{MaxHeaderSize: 8}}, false},
+		"payload exceeds limit": {encrypt(`{"sub":"`+strings.Repeat("a", 64)+`"}`, "JWT"), DecodeOptions{ParseOptions: jws.ParseOptions{MaxPayloadSize: 32}}, false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := DecodeNestedWithOptions(test.compact, StaticDecrypter(km), test.opts)
+			if test.valid && err != nil {
+				t.Error(err)
+			} else if !test.valid && !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("expected ErrInvalidToken but got %v", err)
+			}
+		})
+	}
+}
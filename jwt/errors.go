@@ -0,0 +1,111 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrTokenNotYetValid is returned (maybe wrapped) from verifiers to
+	// indicate that a token is used before its "nbf" claim.
+	ErrTokenNotYetValid = errors.New("token not yet valid")
+
+	// ErrAudienceMismatch is returned (maybe wrapped) from verifiers to
+	// indicate that a token's "aud" claim does not contain the expected
+	// audience.
+	ErrAudienceMismatch = errors.New("audience mismatch")
+
+	// ErrIssuerMismatch is returned (maybe wrapped) from verifiers to
+	// indicate that a token's "iss" claim does not contain the expected
+	// issuer.
+	ErrIssuerMismatch = errors.New("issuer mismatch")
+
+	// ErrTypeMismatch is returned (maybe wrapped) from verifiers to indicate
+	// that a token's "typ" header does not denote the expected type.
+	ErrTypeMismatch = errors.New("type mismatch")
+
+	// ErrTokenReplayed is returned (maybe wrapped) from verifiers to
+	// indicate that a token's "jti" has been seen before.
+	ErrTokenReplayed = errors.New("token replayed")
+
+	// ErrSignatureInvalid is returned (maybe wrapped) from verifiers to
+	// indicate that a token's signature could not be verified.
+	ErrSignatureInvalid = errors.New("signature invalid")
+)
+
+// ErrTokenExpired is returned (maybe wrapped) from verifiers to indicate
+// that a token is used after its expiration time. Use errors.As to obtain
+// the expiration time.
+type ErrTokenExpired struct {
+	// The time the token expired at
+	ExpirationTime time.Time
+}
+
+func (e ErrTokenExpired) Error() string {
+	return fmt.Sprintf("token expired at %s", e.ExpirationTime.Format(time.RFC3339))
+}
+
+// Is reports whether target is an ErrTokenExpired regardless of its
+// expiration time, so that errors.Is(err, ErrTokenExpired{}) is satisfied for
+// every expired token.
+func (e ErrTokenExpired) Is(target error) bool {
+	_, ok := target.(ErrTokenExpired)
+	return ok
+}
+
+// ErrMissingClaim is returned (maybe wrapped) from verifiers to indicate that
+// a token does not carry a required claim. Use errors.As to obtain the
+// claim's name.
+type ErrMissingClaim struct {
+	// The name of the missing claim
+	Name string
+}
+
+func (e ErrMissingClaim) Error() string {
+	return fmt.Sprintf("token is missing %s", e.Name)
+}
+
+// Is reports whether target is an ErrMissingClaim regardless of its name, so
+// that errors.Is(err, ErrMissingClaim{}) is satisfied for every missing
+// claim.
+func (e ErrMissingClaim) Is(target error) bool {
+	_, ok := target.(ErrMissingClaim)
+	return ok
+}
+
+// VerificationErrors is the aggregated error returned from VerifyAll. It
+// matches ErrVerificationFailed as well as each of the contained errors via
+// errors.Is and errors.As.
+type VerificationErrors []error
+
+func (e VerificationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%s: %s", ErrVerificationFailed, strings.Join(msgs, "; "))
+}
+
+func (e VerificationErrors) Is(target error) bool {
+	if target == ErrVerificationFailed {
+		return true
+	}
+
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e VerificationErrors) As(target any) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
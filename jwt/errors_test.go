@@ -0,0 +1,154 @@
+package jwt
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/halimath/jose/jws"
+)
+
+func TestVerify_typedErrors(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	opts := TimeOptions{Clock: FixedClock(now)}
+	exp := now.Add(-time.Minute)
+
+	tests := map[string]struct {
+		verifier Verifier
+		claims   Claims
+		want     error
+	}{
+		"expired":        {ExpirationTimeWithOptions(opts), Claims{ClaimExpirationTime: exp.Unix()}, ErrTokenExpired{}},
+		"not yet valid":  {NotBeforeWithOptions(opts), Claims{ClaimNotBefore: now.Add(time.Minute).Unix()}, ErrTokenNotYetValid},
+		"audience":       {Audience("foo"), Claims{ClaimAudience: "bar"}, ErrAudienceMismatch},
+		"issuer":         {Issuer("foo"), Claims{ClaimIssuer: "bar"}, ErrIssuerMismatch},
+		"missing issuer": {Issuer("foo"), Claims{}, ErrMissingClaim{}},
+		"required":       {Required(ClaimSubject), Claims{}, ErrMissingClaim{}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := (&Token{claims: test.claims}).Verify(test.verifier)
+			if !errors.Is(err, ErrVerificationFailed) {
+				t.Errorf("expected ErrVerificationFailed but got %v", err)
+			}
+			if !errors.Is(err, test.want) {
+				t.Errorf("expected %v but got %v", test.want, err)
+			}
+		})
+	}
+
+	t.Run("expired value", func(t *testing.T) {
+		err := (&Token{claims: Claims{ClaimExpirationTime: exp.Unix()}}).Verify(ExpirationTimeWithOptions(opts))
+
+		var expired ErrTokenExpired
+		if !errors.As(err, &expired) {
+			t.Fatalf("expected ErrTokenExpired but got %v", err)
+		}
+		if !expired.ExpirationTime.Equal(exp) {
+			t.Errorf("expected %s but got %s", exp, expired.ExpirationTime)
+		}
+	})
+
+	t.Run("missing claim name", func(t *testing.T) {
+		err := (&Token{claims: Claims{}}).Verify(Required(ClaimID))
+
+		var missing ErrMissingClaim
+		if !errors.As(err, &missing) {
+			t.Fatalf("expected ErrMissingClaim but got %v", err)
+		}
+		if missing.Name != ClaimID {
+			t.Errorf("expected %s but got %s", ClaimID, missing.Name)
+		}
+	})
+
+	t.Run("signature", func(t *testing.T) {
+		token, err := Sign(jws.HS256([]byte("secret")), StandardClaims{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = token.Verify(Signature(jws.HS256([]byte("another-secret"))))
+		if !errors.Is(err, ErrVerificationFailed) || !errors.Is(err, ErrSignatureInvalid) || !errors.Is(err, jws.ErrInvalidSignature) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestVerifyAll(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	token := &Token{claims: Claims{
+		ClaimIssuer:         "foo",
+		ClaimAudience:       "bar",
+		ClaimExpirationTime: now.Add(-time.Minute).Unix(),
+	}}
+
+	t.Run("failures", func(t *testing.T) {
+		report, err := token.VerifyAll(
+			Issuer("foo"),
+			Audience("spam"),
+			ExpirationTimeWithOptions(TimeOptions{Clock: FixedClock(now)}),
+		)
+
+		if report.Passed() {
+			t.Error("expected report not to pass")
+		}
+
+		passed := make([]bool, len(report))
+		for i, res := range report {
+			passed[i] = res.Passed()
+		}
+		if diff := deep.Equal([]bool{true, false, false}, passed); diff != nil {
+			t.Error(diff)
+		}
+
+		names := make([]string, len(report))
+		for i, res := range report {
+			names[i] = res.Name
+		}
+		if diff := deep.Equal([]string{"iss", "aud", "exp"}, names); diff != nil {
+			t.Error(diff)
+		}
+
+		var errs VerificationErrors
+		if !errors.As(err, &errs) || len(errs) != 2 {
+			t.Fatalf("expected two VerificationErrors but got %v", err)
+		}
+
+		for _, target := range []error{ErrVerificationFailed, ErrAudienceMismatch, ErrTokenExpired{}} {
+			if !errors.Is(err, target) {
+				t.Errorf("expected %v but got %v", target, err)
+			}
+		}
+
+		var expired ErrTokenExpired
+		if !errors.As(err, &expired) {
+			t.Errorf("expected ErrTokenExpired but got %v", err)
+		}
+	})
+
+	t.Run("named", func(t *testing.T) {
+		report, _ := token.VerifyAll(
+			Named("issuer foo", Issuer("foo")),
+			VerifierFunc(func(*Token) error { return nil }),
+		)
+
+		if report[0].Name != "issuer foo" || !report[0].Passed() {
+			t.Errorf("unexpected result: %#v", report[0])
+		}
+		if report[1].Name != "" {
+			t.Errorf("expected unnamed verifier but got %q", report[1].Name)
+		}
+	})
+
+	t.Run("passed", func(t *testing.T) {
+		report, err := token.VerifyAll(Issuer("foo"), Audience("bar"))
+		if err != nil {
+			t.Error(err)
+		}
+		if !report.Passed() {
+			t.Error("expected report to pass")
+		}
+	})
+}
@@ -26,7 +26,7 @@ func Example_standardClaimsWithHS256() {
 			"test",
 			"anotherTest",
 		},
-		ExpirationTime: time.Now().Add(time.Hour).Unix(),
+		ExpirationTime: jwt.NewNumericDate(time.Now().Add(time.Hour)),
 	}
 
 	token, err := jwt.Sign(sig, claims)
@@ -67,7 +67,7 @@ func Example_customClaimsWithHS256() {
 				"test",
 				"anotherTest",
 			},
-			ExpirationTime: time.Now().Add(time.Hour).Unix(),
+			ExpirationTime: jwt.NewNumericDate(time.Now().Add(time.Hour)),
 		},
 		Fullname: "John Doe",
 	}
@@ -120,7 +120,7 @@ func Example_standardClaimsWithRS256() {
 			"test",
 			"anotherTest",
 		},
-		ExpirationTime: time.Now().Add(time.Hour).Unix(),
+		ExpirationTime: jwt.NewNumericDate(time.Now().Add(time.Hour)),
 	}
 
 	token, err := jwt.Sign(signer, claims)
@@ -176,7 +176,7 @@ func Example_standardClaimsWithES256() {
 			"test",
 			"anotherTest",
 		},
-		ExpirationTime: time.Now().Add(time.Hour).Unix(),
+		ExpirationTime: jwt.NewNumericDate(time.Now().Add(time.Hour)),
 	}
 
 	token, err := jwt.Sign(signer, claims)
@@ -0,0 +1,113 @@
+package jwt
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NumericDate implements the NumericDate type defined in RFC 7519 section 2
+// (https://datatracker.ietf.org/doc/html/rfc7519#section-2): the number of
+// seconds since the epoch. NumericDate wraps a time.Time and preserves
+// fractional seconds. It unmarshals from JSON integer and float values and
+// marshals to an integer unless the time contains fractional seconds.
+type NumericDate struct {
+	time.Time
+}
+
+// NewNumericDate returns a pointer to a NumericDate wrapping t.
+func NewNumericDate(t time.Time) *NumericDate {
+	return &NumericDate{Time: t}
+}
+
+// time returns the wrapped time or the zero time if d is nil.
+func (d *NumericDate) time() time.Time {
+	if d == nil {
+		return time.Time{}
+	}
+	return d.Time
+}
+
+func (d NumericDate) MarshalJSON() ([]byte, error) {
+	sec, nsec := d.Unix(), int64(d.Nanosecond())
+	if nsec == 0 {
+		return []byte(strconv.FormatInt(sec, 10)), nil
+	}
+
+	sign := ""
+	if sec < 0 {
+		sign = "-"
+		sec = -(sec + 1)
+		nsec = int64(time.Second) - nsec
+	}
+
+	frac := strings.TrimRight(fmt.Sprintf("%09d", nsec), "0")
+
+	return []byte(fmt.Sprintf("%s%d.%s", sign, sec, frac)), nil
+}
+
+func (d *NumericDate) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+
+	t, err := parseNumericDate(s)
+	if err != nil {
+		return err
+	}
+
+	d.Time = t
+	return nil
+}
+
+// parseNumericDate parses s as a JSON number denoting seconds since the
+// epoch.
+func parseNumericDate(s string) (time.Time, error) {
+	if !json.Valid([]byte(s)) || strings.HasPrefix(s, "\"") {
+		return time.Time{}, fmt.Errorf("invalid NumericDate: %s", s)
+	}
+
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid NumericDate: %s: %v", s, err)
+		}
+		return floatToTime(f), nil
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+
+	sec, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid NumericDate: %s: %v", s, err)
+	}
+
+	var nsec int64
+	if fracPart != "" {
+		if len(fracPart) > 9 {
+			fracPart = fracPart[:9]
+		} else {
+			fracPart += strings.Repeat("0", 9-len(fracPart))
+		}
+
+		nsec, err = strconv.ParseInt(fracPart, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid NumericDate: %s: %v", s, err)
+		}
+
+		if strings.HasPrefix(intPart, "-") {
+			nsec = -nsec
+		}
+	}
+
+	return time.Unix(sec, nsec), nil
+}
+
+func floatToTime(f float64) time.Time {
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(math.Round(frac*1e9)))
+}
@@ -0,0 +1,79 @@
+package jwt
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestNumericDate_JSON(t *testing.T) {
+	tests := map[string]struct {
+		json string
+		time time.Time
+	}{
+		"integer":           {"1672531200", time.Unix(1672531200, 0)},
+		"fraction":          {"1672531200.25", time.Unix(1672531200, 250000000)},
+		"nanoseconds":       {"1672531200.000000001", time.Unix(1672531200, 1)},
+		"negative fraction": {"-1.5", time.Unix(-1, -500000000)},
+		"negative zero":     {"-0.5", time.Unix(0, -500000000)},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var d NumericDate
+			if err := json.Unmarshal([]byte(test.json), &d); err != nil {
+				t.Fatal(err)
+			}
+			if !d.Equal(test.time) {
+				t.Errorf("expected %s but got %s", test.time, d.Time)
+			}
+
+			data, err := json.Marshal(NumericDate{Time: test.time})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.json {
+				t.Errorf("expected %s but got %s", test.json, data)
+			}
+		})
+	}
+
+	t.Run("exponent", func(t *testing.T) {
+		var d NumericDate
+		if err := json.Unmarshal([]byte("1.6725312e9"), &d); err != nil {
+			t.Fatal(err)
+		}
+		if !d.Equal(time.Unix(1672531200, 0)) {
+			t.Errorf("unexpected time: %s", d.Time)
+		}
+	})
+
+	for _, invalid := range []string{`"1672531200"`, `true`, `{}`} {
+		t.Run(invalid, func(t *testing.T) {
+			var d NumericDate
+			if err := json.Unmarshal([]byte(invalid), &d); err == nil {
+				t.Error("expected error but got nil")
+			}
+		})
+	}
+}
+
+func TestClaims_GetTime(t *testing.T) {
+	want := time.Unix(1672531200, 500000000)
+
+	for name, v := range map[string]any{
+		"float64":     1672531200.5,
+		"json.Number": json.Number("1672531200.5"),
+		"NumericDate": NewNumericDate(want),
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := Claims{ClaimExpirationTime: v}.GetTime(ClaimExpirationTime)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(want) {
+				t.Errorf("expected %s but got %s", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package jwt
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrJTIStoreFull is returned (maybe wrapped) from a JTIStore that cannot
+// record any more IDs.
+var ErrJTIStoreFull = errors.New("jti store full")
+
+// JTIStore defines the interface for types that record the IDs of tokens
+// that have been seen to prevent replay. Token IDs are only unique per
+// issuer, so IDs are scoped by the issuer.
+type JTIStore interface {
+	// Add records jti issued by issuer until expiresAt. It returns false if
+	// the pair of issuer and jti has already been recorded and has not yet
+	// expired, true otherwise. Checking for and recording MUST happen
+	// atomically.
+	Add(issuer, jti string, expiresAt time.Time) (bool, error)
+}
+
+// ReplayGuard returns a verifier that rejects tokens whose "jti" claim has
+// been seen before for the same "iss". The token's ID is recorded in store
+// until the token's "exp" plus the leeway from opts. Tokens without "jti" or
+// "exp" are rejected.
+//
+// ReplayGuard applies verifier first and records the token's ID only if all
+// of them accept the token, so that forged or otherwise rejected tokens
+// cannot exhaust store or block legitimate tokens. verifier must contain
+// the signature verifier as well as all other verifiers that may reject the
+// token, e.g. for "aud" or "iss". ReplayGuard panics if verifier is empty.
+func ReplayGuard(store JTIStore, opts TimeOptions, verifier ...Verifier) Verifier {
+	if len(verifier) == 0 {
+		panic("jwt: ReplayGuard requires at least one verifier")
+	}
+
+	return Named("replay", VerifierFunc(func(token *Token) error {
+		for _, v := range verifier {
+			if err := v.Verify(token); err != nil {
+				return err
+			}
+		}
+
+		claims := token.registeredClaims()
+
+		iss, err := claims.GetString(ClaimIssuer)
+		if err != nil {
+			return err
+		}
+
+		jti, err := claims.GetString(ClaimID)
+		if err != nil {
+			return err
+		}
+		if jti == "" {
+			return ErrMissingClaim{Name: ClaimID}
+		}
+
+		exp, err := claims.GetTime(ClaimExpirationTime)
+		if err != nil {
+			return fmt.Errorf("verification of exp failed: %v", err)
+		}
+		if exp.IsZero() {
+			return ErrMissingClaim{Name: ClaimExpirationTime}
+		}
+
+		expiresAt := exp.Add(opts.Leeway)
+		if !opts.now().Before(expiresAt) {
+			return ErrTokenExpired{ExpirationTime: exp}
+		}
+
+		added, err := store.Add(iss, jti, expiresAt)
+		if err != nil {
+			return err
+		}
+		if !added {
+			return fmt.Errorf("%w: jti %q", ErrTokenReplayed, jti)
+		}
+
+		return nil
+	}))
+}
+
+// DefaultMaxJTIs is the default maximum number of IDs held by a
+// MemoryJTIStore.
+const DefaultMaxJTIs = 100000
+
+// MemoryJTIStoreOptions defines the options for a MemoryJTIStore. The zero
+// value uses the SystemClock and DefaultMaxJTIs.
+type MemoryJTIStoreOptions struct {
+	// MaxSize is the maximum number of IDs to record. Once the store is full,
+	// Add returns ErrJTIStoreFull until recorded IDs expire. If MaxSize is
+	// less than or equal to zero DefaultMaxJTIs is used.
+	MaxSize int
+
+	// Clock provides the time to evict expired IDs. If nil, SystemClock is
+	// used.
+	Clock Clock
+}
+
+// MemoryJTIStore is a JTIStore that holds IDs in memory. Expired IDs are
+// evicted when new IDs are added. A MemoryJTIStore is safe for concurrent
+// use.
+type MemoryJTIStore struct {
+	maxSize int
+	clock   Clock
+
+	mu      sync.Mutex
+	entries map[jtiKey]time.Time
+	queue   jtiQueue
+}
+
+// NewMemoryJTIStore creates a new MemoryJTIStore using opts.
+func NewMemoryJTIStore(opts MemoryJTIStoreOptions) *MemoryJTIStore {
+	s := &MemoryJTIStore{
+		maxSize: opts.MaxSize,
+		clock:   opts.Clock,
+		entries: make(map[jtiKey]time.Time),
+	}
+
+	if s.maxSize <= 0 {
+		s.maxSize = DefaultMaxJTIs
+	}
+
+	if s.clock == nil {
+		s.clock = SystemClock
+	}
+
+	return s
+}
+
+func (s *MemoryJTIStore) Add(issuer, jti string, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evict(s.clock.Now())
+
+	key := jtiKey{issuer: issuer, jti: jti}
+	if _, ok := s.entries[key]; ok {
+		return false, nil
+	}
+
+	if len(s.entries) >= s.maxSize {
+		return false, ErrJTIStoreFull
+	}
+
+	s.entries[key] = expiresAt
+	heap.Push(&s.queue, jtiEntry{key: key, expiresAt: expiresAt})
+
+	return true, nil
+}
+
+// Len returns the number of IDs currently recorded in s including expired
+// IDs not yet evicted.
+func (s *MemoryJTIStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.entries)
+}
+
+// evict removes all entries that expired at or before now. s.mu must be held.
+func (s *MemoryJTIStore) evict(now time.Time) {
+	for len(s.queue) > 0 && !s.queue[0].expiresAt.After(now) {
+		e := heap.Pop(&s.queue).(jtiEntry)
+		delete(s.entries, e.key)
+	}
+}
+
+type jtiKey struct {
+	issuer string
+	jti    string
+}
+
+type jtiEntry struct {
+	key       jtiKey
+	expiresAt time.Time
+}
+
+// jtiQueue implements heap.Interface ordering entries by expiration.
+type jtiQueue []jtiEntry
+
+func (q jtiQueue) Len() int           { return len(q) }
+func (q jtiQueue) Less(i, j int) bool { return q[i].expiresAt.Before(q[j].expiresAt) }
+func (q jtiQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *jtiQueue) Push(x any) {
+	*q = append(*q, x.(jtiEntry))
+}
+
+func (q *jtiQueue) Pop() any {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
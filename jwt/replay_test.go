@@ -0,0 +1,203 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/halimath/jose/jws"
+)
+
+func TestReplayGuard(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := ClockFunc(func() time.Time { return now })
+
+	store := NewMemoryJTIStore(MemoryJTIStoreOptions{Clock: clock})
+	accept := VerifierFunc(func(*Token) error { return nil })
+	v := ReplayGuard(store, TimeOptions{Clock: clock, Leeway: time.Minute}, accept)
+
+	token := func(jti string, exp time.Time) *Token {
+		return &Token{claims: Claims{ClaimID: jti, ClaimExpirationTime: exp.Unix()}}
+	}
+
+	if err := v.Verify(token("1", now.Add(time.Minute))); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := v.Verify(token("1", now.Add(time.Minute))); !errors.Is(err, ErrTokenReplayed) {
+		t.Errorf("expected ErrTokenReplayed but got %v", err)
+	}
+
+	if err := v.Verify(token("2", now.Add(time.Minute))); err != nil {
+		t.Error(err)
+	}
+
+	if err := v.Verify(&Token{claims: Claims{ClaimExpirationTime: now.Add(time.Minute).Unix()}}); !errors.Is(err, ErrMissingClaim{}) {
+		t.Errorf("expected ErrMissingClaim but got %v", err)
+	}
+
+	if err := v.Verify(&Token{claims: Claims{ClaimID: "3"}}); !errors.Is(err, ErrMissingClaim{}) {
+		t.Errorf("expected ErrMissingClaim but got %v", err)
+	}
+
+	if err := v.Verify(token("4", now.Add(-2*time.Minute))); !errors.Is(err, ErrTokenExpired{}) {
+		t.Errorf("expected ErrTokenExpired but got %v", err)
+	}
+
+	// Still recorded within the leeway after exp
+	now = now.Add(90 * time.Second)
+	if err := v.Verify(token("1", now.Add(-30*time.Second))); !errors.Is(err, ErrTokenReplayed) {
+		t.Errorf("expected ErrTokenReplayed but got %v", err)
+	}
+
+	// Evicted after exp plus leeway
+	now = now.Add(time.Minute)
+	if err := v.Verify(token("5", now.Add(time.Minute))); err != nil {
+		t.Error(err)
+	}
+	if l := store.Len(); l != 1 {
+		t.Errorf("expected expired IDs to be evicted but got %d entries", l)
+	}
+}
+
+func TestReplayGuard_verifiers(t *testing.T) {
+	sig := jws.HS256([]byte("secret"))
+	forger := jws.HS256([]byte("forged"))
+
+	store := NewMemoryJTIStore(MemoryJTIStoreOptions{})
+	guard := ReplayGuard(store, TimeOptions{}, Signature(sig), Audience("api"))
+
+	sign := func(signer jws.Signer, iss, aud, jti string) *Token {
+		t.Helper()
+		token, err := Sign(signer, map[string]any{
+			ClaimIssuer:         iss,
+			ClaimAudience:       aud,
+			ClaimID:             jti,
+			ClaimExpirationTime: time.Now().Add(time.Minute).Unix(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := Decode(token.Compact())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return decoded
+	}
+
+	t.Run("forged token", func(t *testing.T) {
+		if err := sign(forger, "a", "api", "1").Verify(guard); !errors.Is(err, ErrSignatureInvalid) {
+			t.Errorf("expected ErrSignatureInvalid but got %v", err)
+		}
+	})
+
+	t.Run("forged token with AnyOf", func(t *testing.T) {
+		if err := sign(forger, "a", "api", "2").Verify(AnyOf(guard, Signature(sig))); err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		if err := sign(sig, "a", "other", "3").Verify(guard); !errors.Is(err, ErrAudienceMismatch) {
+			t.Errorf("expected ErrAudienceMismatch but got %v", err)
+		}
+	})
+
+	if l := store.Len(); l != 0 {
+		t.Errorf("expected no IDs to be recorded but got %d", l)
+	}
+
+	t.Run("accepted after rejection", func(t *testing.T) {
+		if err := sign(sig, "a", "api", "3").Verify(guard); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("scoped by issuer", func(t *testing.T) {
+		if err := sign(sig, "a", "api", "4").Verify(guard); err != nil {
+			t.Error(err)
+		}
+		if err := sign(sig, "b", "api", "4").Verify(guard); err != nil {
+			t.Error(err)
+		}
+		if err := sign(sig, "a", "api", "4").Verify(guard); !errors.Is(err, ErrTokenReplayed) {
+			t.Errorf("expected ErrTokenReplayed but got %v", err)
+		}
+	})
+
+	t.Run("custom signature verifier", func(t *testing.T) {
+		custom := VerifierFunc(func(token *Token) error {
+			return token.VerifySignature(sig)
+		})
+		guard := ReplayGuard(store, TimeOptions{}, custom)
+
+		if err := sign(sig, "c", "api", "5").Verify(guard); err != nil {
+			t.Error(err)
+		}
+		if err := sign(sig, "c", "api", "5").Verify(guard); !errors.Is(err, ErrTokenReplayed) {
+			t.Errorf("expected ErrTokenReplayed but got %v", err)
+		}
+	})
+
+	t.Run("no verifiers", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic")
+			}
+		}()
+		ReplayGuard(store, TimeOptions{})
+	})
+}
+
+func TestMemoryJTIStore(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := ClockFunc(func() time.Time { return now })
+
+	t.Run("bounded", func(t *testing.T) {
+		store := NewMemoryJTIStore(MemoryJTIStoreOptions{MaxSize: 2, Clock: clock})
+
+		for i, exp := range []time.Duration{time.Minute, 2 * time.Minute} {
+			if ok, err := store.Add("iss", fmt.Sprint(i), now.Add(exp)); !ok || err != nil {
+				t.Fatalf("expected %d to be added: %v", i, err)
+			}
+		}
+
+		if _, err := store.Add("iss", "2", now.Add(time.Minute)); !errors.Is(err, ErrJTIStoreFull) {
+			t.Errorf("expected ErrJTIStoreFull but got %v", err)
+		}
+
+		now = now.Add(time.Minute)
+
+		if ok, err := store.Add("iss", "2", now.Add(time.Minute)); !ok || err != nil {
+			t.Errorf("expected 2 to be added after eviction: %v", err)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		store := NewMemoryJTIStore(MemoryJTIStoreOptions{Clock: clock})
+
+		var added int32
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ok, err := store.Add("iss", "jti", now.Add(time.Hour))
+				if err != nil {
+					t.Error(err)
+				}
+				if ok {
+					atomic.AddInt32(&added, 1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if added != 1 {
+			t.Errorf("expected exactly one successful add but got %d", added)
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/halimath/jose/internal/sentinel"
+	"github.com/halimath/jose/jwk"
+	"github.com/halimath/jose/jws"
+)
+
+// ErrNoKey is returned (maybe wrapped) from a KeyResolver to indicate that
+// no key is available to verify a given token.
+var ErrNoKey = errors.New("no key found")
+
+// KeyResolver defines the interface for types that select the keys used to
+// verify a token's signature.
+type KeyResolver interface {
+	// ResolveVerifiers returns the candidate verifiers to verify a token with
+	// the given header and claims. Note that the claims have not been
+	// verified when this method is invoked. Implementations MUST NOT modify
+	// claims.
+	ResolveVerifiers(header jws.Header, claims Claims) ([]jws.Verifier, error)
+}
+
+// KeyResolverFunc is a convenience type that wraps a single function as a KeyResolver.
+type KeyResolverFunc func(header jws.Header, claims Claims) ([]jws.Verifier, error)
+
+func (f KeyResolverFunc) ResolveVerifiers(header jws.Header, claims Claims) ([]jws.Verifier, error) {
+	return f(header, claims)
+}
+
+// SignatureFromResolver returns a verifier that verifies the token's signature
+// using the verifiers returned from resolver. The token is accepted if any of
+// the candidate verifiers accepts the signature.
+func SignatureFromResolver(resolver KeyResolver) Verifier {
+	return Named("signature", VerifierFunc(func(token *Token) error {
+		verifiers, err := resolver.ResolveVerifiers(token.Header(), token.allClaims())
+		if err != nil {
+			return sentinel.Wrap(ErrSignatureInvalid, err)
+		}
+
+		if len(verifiers) == 0 {
+			return sentinel.Wrap(ErrSignatureInvalid, ErrNoKey)
+		}
+
+		for _, v := range verifiers {
+			if err = token.VerifySignature(v); err == nil {
+				return nil
+			}
+		}
+
+		return sentinel.Wrap(ErrSignatureInvalid, err)
+	}))
+}
+
+// StaticResolver returns a KeyResolver that resolves verifiers from the
+// given map of key IDs to verifiers. If a token's header contains a "kid"
+// parameter, only the verifier registered for that ID is returned. Otherwise
+// all verifiers are returned ordered by key ID.
+func StaticResolver(verifiers map[string]jws.Verifier) KeyResolver {
+	return KeyResolverFunc(func(header jws.Header, _ Claims) ([]jws.Verifier, error) {
+		if header.KeyID != "" {
+			v, ok := verifiers[header.KeyID]
+			if !ok {
+				return nil, fmt.Errorf("%w: kid %q", ErrNoKey, header.KeyID)
+			}
+			return []jws.Verifier{v}, nil
+		}
+
+		kids := make([]string, 0, len(verifiers))
+		for kid := range verifiers {
+			kids = append(kids, kid)
+		}
+		sort.Strings(kids)
+
+		result := make([]jws.Verifier, len(kids))
+		for i, kid := range kids {
+			result[i] = verifiers[kid]
+		}
+
+		return result, nil
+	})
+}
+
+// KeySetResolver returns a KeyResolver that resolves verifiers from the keys
+// contained in set. If a token's header contains a "kid" parameter, only keys
+// with the same ID are used. Otherwise all keys are used. Keys which cannot
+// be used to verify a signature with the token's "alg" are skipped.
+func KeySetResolver(set jwk.Set) KeyResolver {
+	return KeyResolverFunc(func(header jws.Header, _ Claims) ([]jws.Verifier, error) {
+		var result []jws.Verifier
+
+		for _, k := range set {
+			if header.KeyID != "" && k.ID() != header.KeyID {
+				continue
+			}
+
+			v, err := jwk.Verifier(k, header.Algorithm)
+			if err != nil {
+				continue
+			}
+
+			result = append(result, v)
+		}
+
+		if len(result) == 0 {
+			return nil, fmt.Errorf("%w: kid %q, alg %s", ErrNoKey, header.KeyID, header.Algorithm)
+		}
+
+		return result, nil
+	})
+}
+
+// ChainResolver returns a KeyResolver that asks each of resolvers in order
+// and returns the verifiers from the first resolver that returns at least one
+// verifier.
+func ChainResolver(resolvers ...KeyResolver) KeyResolver {
+	return KeyResolverFunc(func(header jws.Header, claims Claims) ([]jws.Verifier, error) {
+		var lastErr error = ErrNoKey
+
+		for _, r := range resolvers {
+			verifiers, err := r.ResolveVerifiers(header, claims)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+
+			if len(verifiers) > 0 {
+				return verifiers, nil
+			}
+		}
+
+		return nil, lastErr
+	})
+}
@@ -0,0 +1,142 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/halimath/jose/jwk"
+	"github.com/halimath/jose/jws"
+)
+
+func signWithKeyID(t *testing.T, signer jws.Signer, kid string) *Token {
+	j, err := jws.Sign(signer, []byte(`{"sub":"john.doe"}`), jws.Header{
+		Type:  HeaderType,
+		KeyID: kid,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := Decode(j.Compact())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func TestSignatureFromResolver_StaticResolver(t *testing.T) {
+	resolver := StaticResolver(map[string]jws.Verifier{
+		"1": jws.HS256([]byte("secret-1")),
+		"2": jws.HS256([]byte("secret-2")),
+	})
+
+	t.Run("matching kid", func(t *testing.T) {
+		token := signWithKeyID(t, jws.HS256([]byte("secret-2")), "2")
+		if err := token.Verify(SignatureFromResolver(resolver)); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("no kid", func(t *testing.T) {
+		token := signWithKeyID(t, jws.HS256([]byte("secret-2")), "")
+		if err := token.Verify(SignatureFromResolver(resolver)); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("wrong kid", func(t *testing.T) {
+		token := signWithKeyID(t, jws.HS256([]byte("secret-2")), "1")
+		if err := token.Verify(SignatureFromResolver(resolver)); err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+
+	t.Run("unknown kid", func(t *testing.T) {
+		token := signWithKeyID(t, jws.HS256([]byte("secret-2")), "3")
+		if err := token.Verify(SignatureFromResolver(resolver)); !errors.Is(err, ErrVerificationFailed) {
+			t.Errorf("expected ErrVerificationFailed but got %v", err)
+		}
+	})
+}
+
+func TestSignatureFromResolver_KeySetResolver(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := jws.ES256Signer(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := jwk.Set{
+		&jwk.SymmetricKey{
+			KeyDescription: jwk.KeyDescription{KeyID: "hmac"},
+			Bytes:          []byte("secret"),
+		},
+		&jwk.ECDSAPublicKey{
+			KeyDescription: jwk.KeyDescription{KeyID: "ec", KeyUse: jwk.UseSignature},
+			PublicKey:      &privateKey.PublicKey,
+		},
+		&jwk.SymmetricKey{
+			KeyDescription: jwk.KeyDescription{KeyID: "enc", KeyUse: jwk.UseEncryption},
+			Bytes:          []byte("secret"),
+		},
+	}
+
+	resolver := KeySetResolver(set)
+
+	t.Run("ecdsa", func(t *testing.T) {
+		token := signWithKeyID(t, signer, "ec")
+		if err := token.Verify(SignatureFromResolver(resolver)); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("hmac without kid", func(t *testing.T) {
+		token := signWithKeyID(t, jws.HS256([]byte("secret")), "")
+		if err := token.Verify(SignatureFromResolver(resolver)); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("key intended for encryption", func(t *testing.T) {
+		token := signWithKeyID(t, jws.HS256([]byte("secret")), "enc")
+		if err := token.Verify(SignatureFromResolver(resolver)); err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+
+	t.Run("algorithm not matching key", func(t *testing.T) {
+		token := signWithKeyID(t, jws.HS256([]byte("secret")), "ec")
+		if err := token.Verify(SignatureFromResolver(resolver)); err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+}
+
+func TestChainResolver(t *testing.T) {
+	resolver := ChainResolver(
+		StaticResolver(map[string]jws.Verifier{"1": jws.HS256([]byte("secret-1"))}),
+		StaticResolver(map[string]jws.Verifier{"2": jws.HS256([]byte("secret-2"))}),
+	)
+
+	t.Run("second resolver", func(t *testing.T) {
+		token := signWithKeyID(t, jws.HS256([]byte("secret-2")), "2")
+		if err := token.Verify(SignatureFromResolver(resolver)); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("no resolver", func(t *testing.T) {
+		token := signWithKeyID(t, jws.HS256([]byte("secret-2")), "3")
+		if err := token.Verify(SignatureFromResolver(resolver)); err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+}
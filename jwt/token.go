@@ -4,7 +4,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 
+	"github.com/halimath/jose/internal/sentinel"
+	"github.com/halimath/jose/internal/strictjson"
+	"github.com/halimath/jose/jwe"
 	"github.com/halimath/jose/jws"
 )
 
@@ -32,13 +37,24 @@ type Token struct {
 	// the underlying JWS container holding the data
 	jws.JWS
 
-	// The claims contained in this Token in structured form
+	// The claims contained in this Token in structured form. For tokens
+	// decoded by DecodeAs, claims contains only the registered claims
+	// or is nil and all claims are decoded from lazy on first use.
 	claims Claims
+
+	// Decodes all claims on first use or nil if claims contains all claims
+	lazy *lazyClaims
+
+	// The header of the JWE this token has been decrypted from or nil
+	encryptionHeader *jwe.Header
+
+	// The claims decoded by DecodeAs or nil
+	typedClaims *typedClaims
 }
 
 // StandardClaims returns t's RFC defined claims.
 func (t *Token) StandardClaims() (claims StandardClaims) {
-	_ = json.Unmarshal(patchAudClaim(t.Payload()), &claims)
+	_ = json.Unmarshal(t.Payload(), &claims)
 	// We do not handle any error here as both Decode and Sign assure that payload
 	// contains valid data
 	return
@@ -46,24 +62,137 @@ func (t *Token) StandardClaims() (claims StandardClaims) {
 
 // Claims unmarshals the claims JSON data contained in t into the claims value given which must
 // be a pointer to some datastructure that json.Unmarshal can handle.
+// A single string "aud" claim is accepted for fields of type []string.
 // The method returns the error returned from json.Unmarshal
 func (t *Token) UnmarshalClaims(claims interface{}) error {
-	return json.Unmarshal(patchAudClaim(t.Payload()), claims)
+	return unmarshalClaims(t.Payload(), claims)
+}
+
+// unmarshalClaims unmarshals payload into claims. If claims models the "aud"
+// claim as a []string and payload contains a single string audience, the
+// payload is patched using patchAudClaim and unmarshaled again. Types using
+// Audiences never require the patch and are unmarshaled only once.
+func unmarshalClaims(payload []byte, claims any) error {
+	err := json.Unmarshal(payload, claims)
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && strings.EqualFold(typeErr.Field, ClaimAudience) && typeErr.Value == "string" {
+		return json.Unmarshal(patchAudClaim(payload), claims)
+	}
+
+	return err
+}
+
+// patchAudClaim patches an "aud" claim found in in to be a list of strings.
+// According to https://datatracker.ietf.org/doc/html/rfc7519#section-4.1.3
+// "aud" may be either a list of strings or a single string. To enable
+// json.Unmarshal into a []string, this function checks for an "aud" claim
+// and if the value is a bare string it is wrapped in a list. All other
+// values are ignored.
+func patchAudClaim(in []byte) []byte {
+	var claims map[string]json.RawMessage
+	if err := json.Unmarshal(in, &claims); err != nil {
+		// Just return the original payload; the parsing error will pop-up
+		// again
+		return in
+	}
+
+	aud, ok := claims[ClaimAudience]
+	if !ok || len(aud) == 0 || aud[0] != '"' {
+		return in
+	}
+
+	claims[ClaimAudience] = append(append(json.RawMessage{'['}, aud...), ']')
+
+	data, err := json.Marshal(claims)
+	if err != nil {
+		// This can never happen
+		panic(fmt.Sprintf("weird error during 'aud' claim patching: %v", err))
+	}
+
+	return data
 }
 
 // Verify verifies the token to using the given verifier. It returns the
-// first non-nil error received from a verify or nil if no verifier rejects
-// the token.
+// first non-nil error received from a verify wrapped with
+// ErrVerificationFailed or nil if no verifier rejects the token. The
+// verifier's error remains reachable via errors.Is and errors.As.
 func (t *Token) Verify(verifier ...Verifier) error {
 	for _, v := range verifier {
 		if err := v.Verify(t); err != nil {
-			return fmt.Errorf("%w: %s", ErrVerificationFailed, err)
+			return sentinel.Wrap(ErrVerificationFailed, err)
 		}
 	}
 
 	return nil
 }
 
+// VerificationResult holds the outcome of applying a single verifier.
+type VerificationResult struct {
+	// The verifier that has been applied
+	Verifier Verifier
+
+	// The name of the verifier as given to Named or the empty string if the
+	// verifier is unnamed
+	Name string
+
+	// The error returned from Verifier or nil if Verifier accepted the token
+	Err error
+}
+
+// Passed returns true iff r's verifier accepted the token.
+func (r VerificationResult) Passed() bool {
+	return r.Err == nil
+}
+
+// VerificationReport contains one VerificationResult per verifier passed to
+// VerifyAll in the same order.
+type VerificationReport []VerificationResult
+
+// Passed returns true iff all verifiers accepted the token.
+func (r VerificationReport) Passed() bool {
+	for _, res := range r {
+		if !res.Passed() {
+			return false
+		}
+	}
+	return true
+}
+
+// Err returns a VerificationErrors value containing all errors from r or
+// nil if all verifiers accepted the token.
+func (r VerificationReport) Err() error {
+	var errs VerificationErrors
+	for _, res := range r {
+		if !res.Passed() {
+			errs = append(errs, res.Err)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// VerifyAll works like Verify but applies all verifiers even if one rejects
+// the token. It returns a report of the individual results as well as an
+// aggregated VerificationErrors value if any of the verifiers rejected the
+// token.
+func (t *Token) VerifyAll(verifier ...Verifier) (VerificationReport, error) {
+	report := make(VerificationReport, len(verifier))
+	for i, v := range verifier {
+		report[i] = VerificationResult{
+			Verifier: v,
+			Name:     verifierName(v),
+			Err:      v.Verify(t),
+		}
+	}
+
+	return report, report.Err()
+}
+
 // Sign creates a signed JWT and returns it in compact serialization. It uses
 // claims to produce the token's payload by applying json.Marshal to it. It uses
 // signer to create the signature. It returns a non-nil error in case either
@@ -83,14 +212,20 @@ func Sign(signer jws.Signer, claims any) (*Token, error) {
 // The payload is not checked to be a valid JSON string, thus, passing in
 // invalid payload causes SignSerialized to produce an invalid JWT.
 func SignSerialized(signer jws.Signer, payload []byte) (*Token, error) {
-	claims, err := UnmarshalClaims(patchAudClaim(payload))
+	return signSerialized(signer, payload, jws.Header{
+		Type: HeaderType,
+	})
+}
+
+// signSerialized works like SignSerialized but uses header as the token's
+// header. The header's "alg" is set from signer.
+func signSerialized(signer jws.Signer, payload []byte, header jws.Header) (*Token, error) {
+	claims, err := UnmarshalClaims(payload)
 	if err != nil {
 		return nil, fmt.Errorf("Invalid JWT payload: %v", err)
 	}
 
-	j, err := jws.Sign(signer, payload, jws.Header{
-		Type: HeaderType,
-	})
+	j, err := jws.Sign(signer, payload, header)
 	if err != nil {
 		return nil, err
 	}
@@ -101,58 +236,161 @@ func SignSerialized(signer jws.Signer, payload []byte) (*Token, error) {
 	}, nil
 }
 
+// DecodeOptions defines the options for decoding a token. The zero value
+// accepts only tokens with a "typ" header of "JWT".
+type DecodeOptions struct {
+	// AcceptedTypes lists the media types accepted in the "typ" header. Types
+	// are compared according to RFC 7515 section 4.1.9, i.e.
+	// case-insensitive with an optional "application/" prefix. If empty,
+	// only HeaderType is accepted.
+	AcceptedTypes []string
+
+	// AllowMissingType makes decoding accept tokens without a "typ" header.
+	AllowMissingType bool
+
+	// ParseOptions control parsing the underlying JWS. Unless
+	// ParseOptions.AllowDuplicateMembers is set, claims containing duplicate
+	// member names are rejected as required by RFC 7519 section 7.2.
+	ParseOptions jws.ParseOptions
+}
+
+func (o DecodeOptions) acceptsType(typ string) bool {
+	if typ == "" {
+		return o.AllowMissingType
+	}
+
+	if len(o.AcceptedTypes) == 0 {
+		return typesEqual(typ, HeaderType)
+	}
+
+	for _, t := range o.AcceptedTypes {
+		if typesEqual(typ, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeType normalizes the media type typ as described in RFC 7515
+// section 4.1.9 (https://datatracker.ietf.org/doc/html/rfc7515#section-4.1.9).
+func normalizeType(typ string) string {
+	typ = strings.ToLower(typ)
+	if rest := strings.TrimPrefix(typ, "application/"); rest != typ && !strings.Contains(rest, "/") {
+		return rest
+	}
+	return typ
+}
+
+func typesEqual(a, b string) bool {
+	return normalizeType(a) == normalizeType(b)
+}
+
 // Decode decodes the given compact token string, parses header and claims for valid
 // JSON objects and returns a token instance containing the parsed values.
+// Decode only accepts tokens with a "typ" header of "JWT"; use
+// DecodeWithOptions to accept other types.
 func Decode(compact string) (*Token, error) {
-	sig, err := jws.ParseCompact(compact)
+	return DecodeWithOptions(compact, DecodeOptions{})
+}
+
+// DecodeWithOptions works like Decode but applies opts.
+func DecodeWithOptions(compact string, opts DecodeOptions) (*Token, error) {
+	tok, err := parseToken(compact, opts)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		return nil, err
 	}
 
-	if sig.Header().Type != HeaderType {
-		return nil, fmt.Errorf("%w: token is not of type %s: found %q", ErrInvalidToken, HeaderType, sig.Header().Type)
+	if opts.ParseOptions.AllowDuplicateMembers {
+		if err := json.Unmarshal(tok.Payload(), &tok.claims); err != nil {
+			return nil, fmt.Errorf("%w: payload is not a valid JSON object: %v", ErrInvalidToken, err)
+		}
+	} else {
+		// Decode and check for duplicate members in a single pass
+		claims, err := strictjson.DecodeObject(tok.Payload())
+		if err != nil {
+			return nil, payloadError(err)
+		}
+		tok.claims = claims
 	}
 
-	tok := Token{
-		JWS: *sig,
+	return tok, nil
+}
+
+// parseToken parses compact and checks its type without decoding the
+// claims.
+func parseToken(compact string, opts DecodeOptions) (*Token, error) {
+	if strings.Count(compact, ".") == 4 {
+		return nil, fmt.Errorf("%w: token is encrypted; use DecodeNested", ErrInvalidToken)
+	}
+
+	sig, err := jws.ParseCompactWithOptions(compact, opts.ParseOptions)
+	if err != nil {
+		return nil, sentinel.Wrap(ErrInvalidToken, err)
 	}
 
-	if err := json.Unmarshal(patchAudClaim(sig.Payload()), &tok.claims); err != nil {
-		return nil, fmt.Errorf("%w: payload is not a valid JSON object: %v", ErrInvalidToken, err)
+	if !opts.acceptsType(sig.Header().Type) {
+		return nil, fmt.Errorf("%w: token type not accepted: found %q", ErrInvalidToken, sig.Header().Type)
 	}
 
-	return &tok, nil
+	return &Token{
+		JWS: *sig,
+	}, nil
 }
 
-// patchAudClaim patches an "aud" claim found in in to be a list of strings.
-// According to https://datatracker.ietf.org/doc/html/rfc7519#section-4.1.3
-// "aud" may be either a list of strings or a single string. To enable
-// json.Unmarshal we need a slice of strings. Thus, this function checks for
-// an "aud" claim and if the value is a bare string it is wrapped in a list.
-// All other values are ignored.
-// This function causes a double JSON parsing (one run to patch the claim)
-// and another call to actually unmarshal into the StandardClaims struct.
-// This is a known issue.
-func patchAudClaim(in []byte) []byte {
-	var claims map[string]any
-	if err := json.Unmarshal(in, &claims); err != nil {
-		// Just return the original payload; the parsing error will pop-up
-		// again
-		return in
+// payloadError wraps err returned from decoding a token's payload.
+func payloadError(err error) error {
+	if errors.Is(err, strictjson.ErrDuplicateMember) {
+		return sentinel.Wrap(ErrInvalidToken, fmt.Errorf("%w: payload is not a valid JSON object: %v", jws.ErrInvalidCompactJWS, err))
 	}
-	aud, ok := claims["aud"]
-	if !ok {
-		return in
+	return fmt.Errorf("%w: payload is not a valid JSON object: %v", ErrInvalidToken, err)
+}
+
+// lazyClaims decodes a token's claims on first use.
+type lazyClaims struct {
+	once    sync.Once
+	payload []byte
+	claims  Claims
+}
+
+func (l *lazyClaims) get() Claims {
+	l.once.Do(func() {
+		// The payload has been decoded into a typed value before, so it
+		// is known to be a valid JSON object.
+		_ = json.Unmarshal(l.payload, &l.claims)
+	})
+	return l.claims
+}
+
+// allClaims returns all of t's claims, decoding them if necessary.
+func (t *Token) allClaims() Claims {
+	if t.lazy == nil {
+		return t.claims
 	}
-	if s, ok := aud.(string); ok {
-		claims["aud"] = []string{s}
+	return t.lazy.get()
+}
+
+// registeredClaims returns claims containing at least the registered claims
+// of t, decoding all claims only if necessary.
+func (t *Token) registeredClaims() Claims {
+	if t.claims != nil || t.lazy == nil {
+		return t.claims
 	}
+	return t.lazy.get()
+}
 
-	data, err := json.Marshal(claims)
-	if err != nil {
-		// This can never happen
-		panic(fmt.Sprintf("weird error during 'aud' claim patching: %v", err))
+// claimsWith returns claims containing at least the named claim.
+func (t *Token) claimsWith(name string) Claims {
+	if isRegisteredClaim(name) {
+		return t.registeredClaims()
 	}
+	return t.allClaims()
+}
 
-	return data
+func isRegisteredClaim(name string) bool {
+	switch name {
+	case ClaimSubject, ClaimIssuer, ClaimAudience, ClaimExpirationTime, ClaimNotBefore, ClaimIssuedAt, ClaimID:
+		return true
+	}
+	return false
 }
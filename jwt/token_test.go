@@ -2,6 +2,7 @@ package jwt
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
@@ -10,10 +11,10 @@ import (
 )
 
 func TestStandardClaims_marshalling(t *testing.T) {
-	now := time.Now().Unix()
+	now := time.Unix(time.Now().Unix(), 0)
 
 	c := StandardClaims{
-		ExpirationTime: now,
+		ExpirationTime: NewNumericDate(now),
 	}
 
 	marshaled, err := json.Marshal(c)
@@ -69,3 +70,191 @@ func TestDecode(t *testing.T) {
 		t.Error(diff)
 	}
 }
+
+func TestDecode_singleAudience(t *testing.T) {
+	token, err := Sign(jws.None(), map[string]any{
+		"aud": "oauth-server-demo-app",
+		"exp": 1672531200.5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := Decode(token.Compact())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(decoded.StandardClaims(), StandardClaims{
+		Audience:       []string{"oauth-server-demo-app"},
+		ExpirationTime: NewNumericDate(time.Unix(1672531200, 500000000)),
+	}); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestToken_UnmarshalClaims_singleAudience(t *testing.T) {
+	type customClaims struct {
+		Aud  []string `json:"aud"`
+		Name string   `json:"name"`
+	}
+
+	token, err := Sign(jws.None(), map[string]any{
+		"aud":  "oauth-server-demo-app",
+		"name": "john.doe",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := customClaims{Aud: []string{"oauth-server-demo-app"}, Name: "john.doe"}
+
+	var got customClaims
+	if err := token.UnmarshalClaims(&got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+
+	typed, err := ClaimsAs[customClaims](token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(typed, want); diff != nil {
+		t.Error(diff)
+	}
+
+	_, decoded, err := DecodeAs[customClaims](token.Compact())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(decoded, want); diff != nil {
+		t.Error(diff)
+	}
+
+	var legacy LegacyStandardClaims
+	if err := token.UnmarshalClaims(&legacy); err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(legacy.Audience, want.Aud); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestLegacyStandardClaims(t *testing.T) {
+	exp := time.Unix(1672531200, 0)
+
+	var legacy LegacyStandardClaims
+	legacy.SetExpirationTime(exp)
+	legacy.Subject = "john.doe"
+
+	if legacy.ExpirationTime != exp.Unix() {
+		t.Errorf("unexpected exp: %d", legacy.ExpirationTime)
+	}
+
+	if diff := deep.Equal(legacy.StandardClaims(), StandardClaims{
+		Subject:        "john.doe",
+		ExpirationTime: NewNumericDate(exp),
+	}); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestDecodeWithOptions_type(t *testing.T) {
+	signWithType := func(typ string) string {
+		token, err := signSerialized(jws.None(), []byte(`{}`), jws.Header{Type: typ})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token.Compact()
+	}
+
+	accessTokens := DecodeOptions{AcceptedTypes: []string{"at+jwt"}}
+
+	tests := map[string]struct {
+		typ   string
+		opts  DecodeOptions
+		valid bool
+	}{
+		"JWT":                   {"JWT", DecodeOptions{}, true},
+		"lower case":            {"jwt", DecodeOptions{}, true},
+		"application prefix":    {"application/JWT", DecodeOptions{}, true},
+		"missing":               {"", DecodeOptions{}, false},
+		"missing allowed":       {"", DecodeOptions{AllowMissingType: true}, true},
+		"other":                 {"at+jwt", DecodeOptions{}, false},
+		"accepted":              {"at+jwt", accessTokens, true},
+		"accepted with prefix":  {"application/AT+JWT", accessTokens, true},
+		"not accepted":          {"JWT", accessTokens, false},
+		"prefix with subtype":   {"application/foo/at+jwt", accessTokens, false},
+		"other accepted prefix": {"secevent+jwt", DecodeOptions{AcceptedTypes: []string{"application/secevent+jwt"}}, true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := DecodeWithOptions(signWithType(test.typ), test.opts)
+			if test.valid && err != nil {
+				t.Error(err)
+			} else if !test.valid && !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("expected ErrInvalidToken but got %v", err)
+			}
+		})
+	}
+
+	t.Run("Type verifier", func(t *testing.T) {
+		token, err := DecodeWithOptions(signWithType("application/at+JWT"), accessTokens)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := token.Verify(Type("at+jwt")); err != nil {
+			t.Error(err)
+		}
+
+		if err := token.Verify(Type("JWT")); !errors.Is(err, ErrTypeMismatch) {
+			t.Errorf("expected ErrTypeMismatch but got %v", err)
+		}
+	})
+}
+
+func TestDecodeWithOptions_parse(t *testing.T) {
+	duplicate, err := SignSerialized(jws.None(), []byte(`{"sub":"a","sub":"b"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("duplicate claims", func(t *testing.T) {
+		_, err := Decode(duplicate.Compact())
+		if !errors.Is(err, ErrInvalidToken) || !errors.Is(err, jws.ErrInvalidCompactJWS) {
+			t.Errorf("expected ErrInvalidToken and jws.ErrInvalidCompactJWS but got %v", err)
+		}
+	})
+
+	t.Run("duplicate claims allowed", func(t *testing.T) {
+		token, err := DecodeWithOptions(duplicate.Compact(), DecodeOptions{
+			ParseOptions: jws.ParseOptions{AllowDuplicateMembers: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sub := token.StandardClaims().Subject; sub != "b" {
+			t.Errorf("expected last member to win but got %q", sub)
+		}
+	})
+
+	t.Run("two segments", func(t *testing.T) {
+		_, err := Decode("eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0.e30")
+		if !errors.Is(err, ErrInvalidToken) || !errors.Is(err, jws.ErrInvalidCompactJWS) {
+			t.Errorf("expected ErrInvalidToken and jws.ErrInvalidCompactJWS but got %v", err)
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		_, err := DecodeWithOptions(duplicate.Compact(), DecodeOptions{
+			ParseOptions: jws.ParseOptions{MaxTokenSize: 16},
+		})
+		if !errors.Is(err, jws.ErrInvalidCompactJWS) {
+			t.Errorf("expected jws.ErrInvalidCompactJWS but got %v", err)
+		}
+	})
+}
@@ -0,0 +1,202 @@
+package jwt
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/halimath/jose/internal/strictjson"
+)
+
+// DecodeAs works like Decode but additionally decodes the token's claims
+// into a value of type T which is returned along with the token. T usually
+// is a struct embedding StandardClaims. The decoded claims are retained by
+// the token so that ClaimsAs and ClaimsVerifier for the same T do not decode
+// the payload again.
+//
+// As with Decode, the returned token is not verified.
+func DecodeAs[T any](compact string) (*Token, T, error) {
+	return DecodeAsWithOptions[T](compact, DecodeOptions{})
+}
+
+// DecodeAsWithOptions works like DecodeAs but applies opts. The payload is
+// decoded only once, into T. Unless allowed by opts, it is checked for
+// duplicate members beforehand without decoding any values.
+//
+// If T embeds StandardClaims, the registered claims are taken from the
+// decoded value and verifiers for these claims work without decoding the
+// payload again. Otherwise, or if verifiers inspect other claims, the
+// generic claims are decoded once on first use.
+func DecodeAsWithOptions[T any](compact string, opts DecodeOptions) (*Token, T, error) {
+	var claims T
+
+	tok, err := parseToken(compact, opts)
+	if err != nil {
+		return nil, claims, err
+	}
+
+	payload := tok.Payload()
+
+	if !opts.ParseOptions.AllowDuplicateMembers {
+		if err := strictjson.CheckDuplicateMembers(payload); err != nil {
+			return nil, claims, payloadError(err)
+		}
+	}
+
+	if p := bytes.TrimLeft(payload, " \t\r\n"); len(p) == 0 || p[0] != '{' {
+		return nil, claims, fmt.Errorf("%w: payload is not a valid JSON object", ErrInvalidToken)
+	}
+
+	if err := unmarshalClaims(payload, &claims); err != nil {
+		return nil, claims, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	tok.typedClaims = &typedClaims{typ: typeOf[T](), value: claims}
+	tok.claims = registeredClaimsOf(&claims)
+	tok.lazy = &lazyClaims{payload: payload}
+
+	return tok, claims, nil
+}
+
+// registeredClaimer is implemented by pointers to types embedding
+// StandardClaims.
+type registeredClaimer interface {
+	standardClaims() *StandardClaims
+}
+
+func (s *StandardClaims) standardClaims() *StandardClaims {
+	return s
+}
+
+// registeredClaimsOf returns the registered claims from v, which must be a
+// pointer to decoded claims, or nil if v does not embed StandardClaims or
+// other fields of v shadow the ones of StandardClaims.
+func registeredClaimsOf(v any) Claims {
+	rc, ok := v.(registeredClaimer)
+	if !ok || !routesRegisteredClaims(reflect.TypeOf(v).Elem()) {
+		return nil
+	}
+
+	s := rc.standardClaims()
+	if s == nil {
+		return nil
+	}
+
+	claims := make(Claims)
+	if s.Subject != "" {
+		claims[ClaimSubject] = s.Subject
+	}
+	if s.Issuer != "" {
+		claims[ClaimIssuer] = s.Issuer
+	}
+	if s.Audience != nil {
+		claims[ClaimAudience] = s.Audience
+	}
+	if s.ExpirationTime != nil {
+		claims[ClaimExpirationTime] = s.ExpirationTime
+	}
+	if s.NotBefore != nil {
+		claims[ClaimNotBefore] = s.NotBefore
+	}
+	if s.IssuedAt != nil {
+		claims[ClaimIssuedAt] = s.IssuedAt
+	}
+	if s.ID != "" {
+		claims[ClaimID] = s.ID
+	}
+
+	return claims
+}
+
+var (
+	standardClaimsType = reflect.TypeOf(StandardClaims{})
+
+	// Caches the results of routesRegisteredClaims by type
+	routingCache sync.Map
+)
+
+// routesRegisteredClaims reports whether encoding/json decodes all
+// registered claims into the fields of StandardClaims embedded in typ, i.e.
+// whether no other field of typ uses the name of a registered claim.
+func routesRegisteredClaims(typ reflect.Type) bool {
+	if v, ok := routingCache.Load(typ); ok {
+		return v.(bool)
+	}
+
+	routes := true
+	if typ.Kind() == reflect.Struct {
+		for _, f := range reflect.VisibleFields(typ) {
+			if f.Anonymous || !f.IsExported() {
+				continue
+			}
+
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+
+			if !isRegisteredClaim(strings.ToLower(name)) {
+				continue
+			}
+
+			owner := typ
+			if len(f.Index) > 1 {
+				owner = typ.FieldByIndex(f.Index[:len(f.Index)-1]).Type
+				if owner.Kind() == reflect.Pointer {
+					owner = owner.Elem()
+				}
+			}
+			if owner != standardClaimsType {
+				routes = false
+				break
+			}
+		}
+	}
+
+	routingCache.Store(typ, routes)
+	return routes
+}
+
+// typedClaims holds claims decoded by DecodeAs along with the type they have
+// been decoded as.
+type typedClaims struct {
+	typ   reflect.Type
+	value any
+}
+
+// typeOf returns the reflect.Type of T, which, unlike reflect.TypeOf, is
+// the interface type itself if T is an interface.
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// ClaimsAs returns t's claims decoded into a value of type T. As with
+// Token.UnmarshalClaims, a single string "aud" claim is accepted for fields
+// of type []string. If t has been decoded using DecodeAs with exactly the
+// same T, the claims decoded there are returned.
+func ClaimsAs[T any](t *Token) (T, error) {
+	if t.typedClaims != nil && t.typedClaims.typ == typeOf[T]() {
+		return t.typedClaims.value.(T), nil
+	}
+
+	var claims T
+	err := unmarshalClaims(t.Payload(), &claims)
+	return claims, err
+}
+
+// ClaimsVerifier returns a verifier that passes the token's claims decoded
+// as T to verify. Use DecodeAs to decode the claims only once.
+func ClaimsVerifier[T any](verify func(claims T) error) Verifier {
+	return Named("claims", VerifierFunc(func(token *Token) error {
+		claims, err := ClaimsAs[T](token)
+		if err != nil {
+			return err
+		}
+		return verify(claims)
+	}))
+}
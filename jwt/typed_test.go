@@ -0,0 +1,238 @@
+package jwt
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/halimath/jose/jws"
+)
+
+type testClaims struct {
+	StandardClaims
+	Role string `json:"role"`
+}
+
+func TestDecodeAs(t *testing.T) {
+	sig := jws.HS256([]byte("secret"))
+
+	want := testClaims{
+		StandardClaims: StandardClaims{
+			Subject:  "john.doe",
+			Audience: []string{"test"},
+		},
+		Role: "admin",
+	}
+
+	token, err := Sign(sig, map[string]any{
+		"sub":  "john.doe",
+		"aud":  "test",
+		"role": "admin",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, claims, err := DecodeAs[testClaims](token.Compact())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(want, claims); diff != nil {
+		t.Error(diff)
+	}
+
+	t.Run("ClaimsAs", func(t *testing.T) {
+		got, err := ClaimsAs[testClaims](decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := deep.Equal(want, got); diff != nil {
+			t.Error(diff)
+		}
+
+		std, err := ClaimsAs[StandardClaims](decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := deep.Equal(want.StandardClaims, std); diff != nil {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("ClaimsVerifier", func(t *testing.T) {
+		errNotAdmin := errors.New("not an admin")
+		isAdmin := func(role string) Verifier {
+			return ClaimsVerifier(func(c testClaims) error {
+				if c.Role != role {
+					return errNotAdmin
+				}
+				return nil
+			})
+		}
+
+		if err := decoded.Verify(Signature(sig), isAdmin("admin")); err != nil {
+			t.Error(err)
+		}
+
+		if err := decoded.Verify(Signature(sig), isAdmin("root")); !errors.Is(err, errNotAdmin) {
+			t.Errorf("expected errNotAdmin but got %v", err)
+		}
+	})
+
+	t.Run("invalid claims", func(t *testing.T) {
+		if _, _, err := DecodeAs[struct {
+			Role int `json:"role"`
+		}](token.Compact()); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("expected ErrInvalidToken but got %v", err)
+		}
+	})
+}
+
+func TestClaimsAs_interfaceType(t *testing.T) {
+	token, err := Sign(jws.None(), map[string]any{"role": "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, _, err := DecodeAs[testClaims](token.Compact())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ClaimsAs[any](decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(map[string]any{"role": "admin"}, got); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestDecodeAsWithOptions(t *testing.T) {
+	token, err := signSerialized(jws.None(), []byte(`{"role":"admin"}`), jws.Header{Type: "at+jwt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := DecodeAs[testClaims](token.Compact()); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken but got %v", err)
+	}
+
+	_, claims, err := DecodeAsWithOptions[testClaims](token.Compact(), DecodeOptions{AcceptedTypes: []string{"at+jwt"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Role != "admin" {
+		t.Errorf("unexpected role: %q", claims.Role)
+	}
+}
+
+// countingRole counts how often it is decoded.
+type countingRole struct{}
+
+var roleDecodes int
+
+func (r *countingRole) UnmarshalJSON([]byte) error {
+	roleDecodes++
+	return nil
+}
+
+func TestDecodeAsWithOptions_decodesOnce(t *testing.T) {
+	type claims struct {
+		StandardClaims
+		Role countingRole `json:"role"`
+	}
+
+	now := time.Now()
+
+	token, err := Sign(jws.None(), map[string]any{
+		ClaimIssuer:         "issuer",
+		ClaimAudience:       "api",
+		ClaimID:             "1",
+		ClaimExpirationTime: now.Add(time.Hour).Unix(),
+		"role":              "admin",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roleDecodes = 0
+
+	decoded, _, err := DecodeAs[claims](token.Compact())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = decoded.Verify(
+		Issuer("issuer"),
+		Audience("api"),
+		ExpirationTime(0),
+		Required(ClaimID, ClaimExpirationTime),
+		ClaimsVerifier(func(claims) error { return nil }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if roleDecodes != 1 {
+		t.Errorf("expected claims to be decoded once but got %d", roleDecodes)
+	}
+
+	if decoded.lazy.claims != nil || decoded.claims.Has("role") {
+		t.Error("expected payload not to be decoded into generic claims")
+	}
+
+	if err := decoded.Verify(Required("role")); err != nil {
+		t.Error(err)
+	}
+
+	if roleDecodes != 1 {
+		t.Errorf("expected claims to be decoded once but got %d", roleDecodes)
+	}
+}
+
+func TestDecodeAsWithOptions_shadowedClaims(t *testing.T) {
+	type claims struct {
+		StandardClaims
+		Expires int64 `json:"exp"`
+	}
+
+	token, err := Sign(jws.None(), map[string]any{
+		ClaimExpirationTime: time.Now().Add(-time.Hour).Unix(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, c, err := DecodeAs[claims](token.Compact())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Expires == 0 {
+		t.Error("expected exp to be decoded into the shadowing field")
+	}
+
+	if err := decoded.Verify(ExpirationTime(0)); !errors.Is(err, ErrTokenExpired{}) {
+		t.Errorf("expected ErrTokenExpired but got %v", err)
+	}
+}
+
+func TestDecodeAsWithOptions_duplicateMembers(t *testing.T) {
+	token, err := signSerialized(jws.None(), []byte(`{"role":"a","role":"b"}`), jws.Header{Type: HeaderType})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := DecodeAs[testClaims](token.Compact()); !errors.Is(err, jws.ErrInvalidCompactJWS) {
+		t.Errorf("expected ErrInvalidCompactJWS but got %v", err)
+	}
+
+	opts := DecodeOptions{ParseOptions: jws.ParseOptions{AllowDuplicateMembers: true}}
+	if _, c, err := DecodeAsWithOptions[testClaims](token.Compact(), opts); err != nil || c.Role != "b" {
+		t.Errorf("expected last member to be used: %q, %v", c.Role, err)
+	}
+}
@@ -2,8 +2,10 @@ package jwt
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/halimath/jose/internal/sentinel"
 	"github.com/halimath/jose/jws"
 )
 
@@ -20,118 +22,291 @@ func (f VerifierFunc) Verify(token *Token) error {
 	return f(token)
 }
 
+// Named returns a verifier that applies verifier and reports name as its
+// Name. VerifyAll records the name in each VerificationResult so that
+// results can be identified without relying on their position. The
+// verifiers provided by this package are named after the claim or header
+// parameter they verify, e.g. "iss", "aud", "exp" or "typ", or after their
+// purpose, e.g. "signature", "required", "max age", "claims", "replay" and
+// "all of", "any of", "not" or "when" for the combinators.
+func Named(name string, verifier Verifier) Verifier {
+	return &namedVerifier{name: name, Verifier: verifier}
+}
+
+type namedVerifier struct {
+	Verifier
+	name string
+}
+
+// Name returns the name the verifier has been created with.
+func (v *namedVerifier) Name() string {
+	return v.name
+}
+
+// verifierName returns the name of v if v provides a Name method, e.g.
+// because it has been created using Named, or the empty string.
+func verifierName(v Verifier) string {
+	if n, ok := v.(interface{ Name() string }); ok {
+		return n.Name()
+	}
+	return ""
+}
+
 // --
 
 // Signature returns a verifier that verifies the token's signature using the given signature method.
 func Signature(signatureVerifier jws.Verifier) Verifier {
-	return VerifierFunc(func(token *Token) error {
+	return Named("signature", VerifierFunc(func(token *Token) error {
 		err := token.VerifySignature(signatureVerifier)
 		if err != nil {
-			return fmt.Errorf("%w: %s", ErrVerificationFailed, err)
+			return sentinel.Wrap(ErrSignatureInvalid, err)
 		}
 		return nil
-	})
+	}))
+}
+
+// Type returns a verifier that verifies that the token's "typ" header
+// denotes the media type typ, e.g. "at+jwt" for OAuth 2.0 access tokens
+// (RFC 9068). Types are compared according to RFC 7515 section 4.1.9. Use
+// this verifier to prevent tokens of one type being accepted as another.
+func Type(typ string) Verifier {
+	return Named("typ", VerifierFunc(func(token *Token) error {
+		if !typesEqual(token.Header().Type, typ) {
+			return fmt.Errorf("%w: expected %s but found %q", ErrTypeMismatch, typ, token.Header().Type)
+		}
+		return nil
+	}))
+}
+
+// Required returns a verifier that verifies that the token carries all of
+// the given claims. The claims' values are not verified.
+func Required(claims ...string) Verifier {
+	return Named("required", VerifierFunc(func(token *Token) error {
+		for _, c := range claims {
+			if !token.claimsWith(c).Has(c) {
+				return ErrMissingClaim{Name: c}
+			}
+		}
+		return nil
+	}))
 }
 
 // Issuer returns a verifier that verifies the issuer for a given value.
 func Issuer(issuer string) Verifier {
-	return VerifierFunc(func(token *Token) error {
-		iss, err := token.claims.GetString(ClaimIssuer)
+	return IssuerIn(issuer)
+}
+
+// IssuerIn returns a verifier that verifies that the issuer is one of the
+// given values.
+func IssuerIn(issuers ...string) Verifier {
+	return IssuerInWithOptions(issuers, IssuerOptions{})
+}
+
+// IssuerOptions defines the options for verifying a token's issuer. The zero
+// value compares issuers exactly.
+type IssuerOptions struct {
+	// IgnoreTrailingSlash makes the verifier ignore a single trailing slash
+	// on both the expected and the token's issuer, so that
+	// "https://example.com/" matches "https://example.com".
+	IgnoreTrailingSlash bool
+}
+
+func (o IssuerOptions) normalize(iss string) string {
+	if o.IgnoreTrailingSlash {
+		return strings.TrimSuffix(iss, "/")
+	}
+	return iss
+}
+
+// IssuerInWithOptions works like IssuerIn but compares issuers according to opts.
+func IssuerInWithOptions(issuers []string, opts IssuerOptions) Verifier {
+	return Named("iss", VerifierFunc(func(token *Token) error {
+		claims := token.registeredClaims()
+		if !claims.Has(ClaimIssuer) {
+			return ErrMissingClaim{Name: ClaimIssuer}
+		}
+		iss, err := claims.GetString(ClaimIssuer)
 		if err != nil {
-			return fmt.Errorf("invalid issuer: %v", err)
+			return sentinel.Wrap(ErrIssuerMismatch, err)
 		}
-		if iss != issuer {
-			return fmt.Errorf("invalid issuer: %s", iss)
+		for _, issuer := range issuers {
+			if opts.normalize(iss) == opts.normalize(issuer) {
+				return nil
+			}
 		}
-		return nil
-	})
+		return fmt.Errorf("%w: %s", ErrIssuerMismatch, iss)
+	}))
 }
 
 // Audience returns a verifier that verifies whether the audience claim contains a given value.
 func Audience(audience string) Verifier {
-	return VerifierFunc(func(token *Token) error {
-		aud, err := token.claims.GetStringSlice(ClaimAudience)
-		if err != nil {
-			return fmt.Errorf("error verifying aud: %v", err)
+	return AudienceAnyOf(audience)
+}
+
+// AudienceAnyOf returns a verifier that verifies whether the audience claim
+// contains at least one of the given values.
+func AudienceAnyOf(audiences ...string) Verifier {
+	return audienceVerifier(func(aud []string) error {
+		for _, a := range audiences {
+			if contains(aud, a) {
+				return nil
+			}
 		}
 
-		if len(aud) == 0 {
-			return fmt.Errorf("token is missing aud claim")
+		if len(audiences) == 1 {
+			return fmt.Errorf("%w: missing required audience %s", ErrAudienceMismatch, audiences[0])
 		}
+		return fmt.Errorf("%w: missing any of required audiences %s", ErrAudienceMismatch, strings.Join(audiences, ", "))
+	})
+}
 
-		for _, aud := range aud {
-			if aud == audience {
-				return nil
+// AudienceAllOf returns a verifier that verifies whether the audience claim
+// contains all of the given values. The claim may contain additional values.
+func AudienceAllOf(audiences ...string) Verifier {
+	return audienceVerifier(func(aud []string) error {
+		for _, a := range audiences {
+			if !contains(aud, a) {
+				return fmt.Errorf("%w: missing required audience %s", ErrAudienceMismatch, a)
 			}
 		}
+		return nil
+	})
+}
 
-		return fmt.Errorf("missing required audience: %s", audience)
+// AudienceExact returns a verifier that verifies whether the audience claim
+// contains exactly the given values in any order.
+func AudienceExact(audiences ...string) Verifier {
+	return audienceVerifier(func(aud []string) error {
+		for _, a := range audiences {
+			if !contains(aud, a) {
+				return fmt.Errorf("%w: missing required audience %s", ErrAudienceMismatch, a)
+			}
+		}
+		for _, a := range aud {
+			if !contains(audiences, a) {
+				return fmt.Errorf("%w: unexpected audience %s", ErrAudienceMismatch, a)
+			}
+		}
+		return nil
 	})
 }
 
+// audienceVerifier creates a verifier that extracts the token's audience
+// claim and passes it to check.
+func audienceVerifier(check func(aud []string) error) Verifier {
+	return Named("aud", VerifierFunc(func(token *Token) error {
+		aud, err := token.registeredClaims().GetStringSlice(ClaimAudience)
+		if err != nil {
+			return sentinel.Wrap(ErrAudienceMismatch, err)
+		}
+
+		if len(aud) == 0 {
+			return ErrMissingClaim{Name: ClaimAudience}
+		}
+
+		return check(aud)
+	}))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 // NotBefore returns a verifier that verifies that a token is not used before the given not before time.
 // The function accepts a leeway to compensate for differences in server time.
 // If the token does not carry a not before claim, this verifier rejects the token.
 func NotBefore(leeway time.Duration) Verifier {
-	return VerifierFunc(func(token *Token) error {
-		notBefore, err := token.claims.GetTime(ClaimNotBefore)
+	return NotBeforeWithOptions(TimeOptions{Leeway: leeway})
+}
+
+// NotBeforeWithOptions works like NotBefore but uses the clock and leeway from opts.
+// The token is accepted if the current time plus leeway is not before nbf.
+func NotBeforeWithOptions(opts TimeOptions) Verifier {
+	return Named("nbf", VerifierFunc(func(token *Token) error {
+		notBefore, err := token.registeredClaims().GetTime(ClaimNotBefore)
 		if err != nil {
 			return fmt.Errorf("error verifying nbf: %v", err)
 		}
 
 		if notBefore.IsZero() {
-			return fmt.Errorf("token is missing nbf")
+			if opts.Optional {
+				return nil
+			}
+			return ErrMissingClaim{Name: ClaimNotBefore}
 		}
 
-		delta := time.Until(notBefore)
-		if delta > leeway {
-			return fmt.Errorf("token used before nbf: %s", notBefore.Format(time.RFC3339))
+		if opts.now().Add(opts.Leeway).Before(notBefore) {
+			return fmt.Errorf("%w: used before nbf %s", ErrTokenNotYetValid, notBefore.Format(time.RFC3339))
 		}
 
 		return nil
-	})
+	}))
 }
 
 // ExpirationTime returns a verifier that verifies that a token is not expired.
 // The function accepts a leeway to compensate for differences in server time.
 // If the token does not carry a expiration time claim, this verifier rejects the token.
 func ExpirationTime(leeway time.Duration) Verifier {
-	return VerifierFunc(func(token *Token) error {
-		exp, err := token.claims.GetTime(ClaimExpirationTime)
+	return ExpirationTimeWithOptions(TimeOptions{Leeway: leeway})
+}
+
+// ExpirationTimeWithOptions works like ExpirationTime but uses the clock and leeway from opts.
+// The token is accepted if the current time minus leeway is before exp.
+func ExpirationTimeWithOptions(opts TimeOptions) Verifier {
+	return Named("exp", VerifierFunc(func(token *Token) error {
+		exp, err := token.registeredClaims().GetTime(ClaimExpirationTime)
 		if err != nil {
 			return fmt.Errorf("verification of exp failed: %v", err)
 		}
 
 		if exp.IsZero() {
-			return fmt.Errorf("token is missing exp")
+			if opts.Optional {
+				return nil
+			}
+			return ErrMissingClaim{Name: ClaimExpirationTime}
 		}
 
-		if time.Since(exp) > leeway {
-			return fmt.Errorf("token used after exp: %s", exp.Format(time.RFC3339))
+		if !opts.now().Add(-opts.Leeway).Before(exp) {
+			return ErrTokenExpired{ExpirationTime: exp}
 		}
 
 		return nil
-	})
+	}))
 }
 
 // MaxAge returns a verifier that verifies that a token is not older than the given duration.
 // The verifier uses the issued at claim. If the token does not carry an issued at claim, this verifier
 // rejects the token.
 func MaxAge(maxAge time.Duration) Verifier {
-	return VerifierFunc(func(token *Token) error {
-		iat, err := token.claims.GetTime(ClaimIssuedAt)
+	return MaxAgeWithOptions(maxAge, TimeOptions{})
+}
+
+// MaxAgeWithOptions works like MaxAge but uses the clock and leeway from opts.
+// The token is accepted if the current time minus leeway is not after iat plus maxAge.
+// A token that is too old is rejected with an ErrTokenExpired carrying iat plus maxAge.
+func MaxAgeWithOptions(maxAge time.Duration, opts TimeOptions) Verifier {
+	return Named("max age", VerifierFunc(func(token *Token) error {
+		iat, err := token.registeredClaims().GetTime(ClaimIssuedAt)
 		if err != nil {
 			return fmt.Errorf("verification of iat failed: %v", err)
 		}
 
 		if iat.IsZero() {
-			return fmt.Errorf("token is missing iat")
+			if opts.Optional {
+				return nil
+			}
+			return ErrMissingClaim{Name: ClaimIssuedAt}
 		}
 
-		if iat.Before(time.Now().Add(-maxAge)) {
-			return fmt.Errorf("token too old: %s", iat.Format(time.RFC3339))
+		if opts.now().Add(-opts.Leeway).After(iat.Add(maxAge)) {
+			return ErrTokenExpired{ExpirationTime: iat.Add(maxAge)}
 		}
 
 		return nil
-	})
+	}))
 }
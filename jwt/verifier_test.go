@@ -209,3 +209,144 @@ func TestVerifySignature(t *testing.T) {
 		}
 	})
 }
+
+func TestTimeVerifiersWithOptions(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	opts := TimeOptions{
+		Clock:  FixedClock(now),
+		Leeway: 5 * time.Second,
+	}
+
+	tests := map[string]struct {
+		verifier Verifier
+		claims   Claims
+		valid    bool
+	}{
+		"nbf in the past":   {NotBeforeWithOptions(opts), Claims{ClaimNotBefore: now.Add(-time.Minute).Unix()}, true},
+		"nbf within leeway": {NotBeforeWithOptions(opts), Claims{ClaimNotBefore: now.Add(5 * time.Second).Unix()}, true},
+		"nbf beyond leeway": {NotBeforeWithOptions(opts), Claims{ClaimNotBefore: now.Add(6 * time.Second).Unix()}, false},
+
+		"exp in the future": {ExpirationTimeWithOptions(opts), Claims{ClaimExpirationTime: now.Add(time.Minute).Unix()}, true},
+		"exp within leeway": {ExpirationTimeWithOptions(opts), Claims{ClaimExpirationTime: now.Add(-4 * time.Second).Unix()}, true},
+		"exp at leeway":     {ExpirationTimeWithOptions(opts), Claims{ClaimExpirationTime: now.Add(-5 * time.Second).Unix()}, false},
+		"exp historical":    {ExpirationTimeWithOptions(TimeOptions{Clock: FixedClock(time.Unix(1000, 0))}), Claims{ClaimExpirationTime: int64(1001)}, true},
+
+		"iat recent enough": {MaxAgeWithOptions(time.Minute, opts), Claims{ClaimIssuedAt: now.Add(-time.Minute).Unix()}, true},
+		"iat within leeway": {MaxAgeWithOptions(time.Minute, opts), Claims{ClaimIssuedAt: now.Add(-65 * time.Second).Unix()}, true},
+		"iat too old":       {MaxAgeWithOptions(time.Minute, opts), Claims{ClaimIssuedAt: now.Add(-66 * time.Second).Unix()}, false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.verifier.Verify(&Token{claims: test.claims})
+			if test.valid && err != nil {
+				t.Error(err)
+			} else if !test.valid && err == nil {
+				t.Error("expected error but got nil")
+			}
+		})
+	}
+}
+
+func TestTimeVerifiersOptional(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	opts := TimeOptions{
+		Clock:    FixedClock(now),
+		Optional: true,
+	}
+
+	verifiers := map[string]Verifier{
+		ClaimNotBefore:      NotBeforeWithOptions(opts),
+		ClaimExpirationTime: ExpirationTimeWithOptions(opts),
+		ClaimIssuedAt:       MaxAgeWithOptions(time.Minute, opts),
+	}
+
+	for claim, v := range verifiers {
+		t.Run(claim, func(t *testing.T) {
+			if err := v.Verify(&Token{claims: Claims{}}); err != nil {
+				t.Errorf("missing claim: %v", err)
+			}
+
+			if err := v.Verify(&Token{claims: Claims{claim: "foo"}}); err == nil {
+				t.Error("invalid claim: expected error but got nil")
+			}
+		})
+	}
+
+	t.Run("enforced if present", func(t *testing.T) {
+		if err := verifiers[ClaimExpirationTime].Verify(&Token{claims: Claims{ClaimExpirationTime: now.Add(-time.Hour).Unix()}}); err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+}
+
+func TestVerifyRequired(t *testing.T) {
+	v := Required(ClaimExpirationTime, ClaimSubject)
+
+	t.Run("present", func(t *testing.T) {
+		if err := v.Verify(&Token{claims: Claims{ClaimExpirationTime: 1, ClaimSubject: "foo"}}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		if err := v.Verify(&Token{claims: Claims{ClaimSubject: "foo"}}); err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+}
+
+func TestVerifyIssuerIn(t *testing.T) {
+	tests := map[string]struct {
+		verifier Verifier
+		iss      string
+		valid    bool
+	}{
+		"first":                   {IssuerIn("a", "b"), "a", true},
+		"second":                  {IssuerIn("a", "b"), "b", true},
+		"other":                   {IssuerIn("a", "b"), "c", false},
+		"trailing slash exact":    {IssuerIn("https://example.com"), "https://example.com/", false},
+		"trailing slash in token": {IssuerInWithOptions([]string{"https://example.com"}, IssuerOptions{IgnoreTrailingSlash: true}), "https://example.com/", true},
+		"trailing slash expected": {IssuerInWithOptions([]string{"https://example.com/"}, IssuerOptions{IgnoreTrailingSlash: true}), "https://example.com", true},
+		"normalized other":        {IssuerInWithOptions([]string{"https://example.com"}, IssuerOptions{IgnoreTrailingSlash: true}), "https://example.org/", false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.verifier.Verify(&Token{claims: Claims{ClaimIssuer: test.iss}})
+			if test.valid && err != nil {
+				t.Error(err)
+			} else if !test.valid && err == nil {
+				t.Error("expected error but got nil")
+			}
+		})
+	}
+}
+
+func TestVerifyAudienceSets(t *testing.T) {
+	tests := map[string]struct {
+		verifier Verifier
+		aud      any
+		valid    bool
+	}{
+		"any of match":        {AudienceAnyOf("a", "b"), []string{"b", "c"}, true},
+		"any of no match":     {AudienceAnyOf("a", "b"), []string{"c"}, false},
+		"all of match":        {AudienceAllOf("a", "b"), []string{"b", "c", "a"}, true},
+		"all of partial":      {AudienceAllOf("a", "b"), []string{"a", "c"}, false},
+		"exact match":         {AudienceExact("a", "b"), []any{"b", "a"}, true},
+		"exact single string": {AudienceExact("a"), "a", true},
+		"exact additional":    {AudienceExact("a", "b"), []string{"a", "b", "c"}, false},
+		"exact missing":       {AudienceExact("a", "b"), []string{"a"}, false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.verifier.Verify(&Token{claims: Claims{ClaimAudience: test.aud}})
+			if test.valid && err != nil {
+				t.Error(err)
+			} else if !test.valid && err == nil {
+				t.Error("expected error but got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/halimath/jose/jwks"
+	"github.com/halimath/jose/jwt"
+)
+
+const (
+	// WellKnownOpenIDConfiguration is the well-known URI suffix of the
+	// OpenID Connect provider configuration.
+	WellKnownOpenIDConfiguration = "/.well-known/openid-configuration"
+
+	// WellKnownOAuthAuthorizationServer is the well-known URI suffix of the
+	// OAuth 2.0 authorization server metadata as registered in RFC 8414
+	// section 7.3.
+	WellKnownOAuthAuthorizationServer = "/.well-known/oauth-authorization-server"
+
+	// DefaultMaxResponseSize is the default maximum size in bytes of a
+	// metadata document.
+	DefaultMaxResponseSize = 1 << 20
+)
+
+var (
+	// ErrInvalidIssuer is returned when an issuer identifier is not an https
+	// URL without query and fragment.
+	ErrInvalidIssuer = errors.New("invalid issuer")
+
+	// ErrIssuerMismatch is returned when the issuer contained in the
+	// metadata differs from the issuer the metadata was requested for.
+	ErrIssuerMismatch = errors.New("issuer mismatch")
+
+	// ErrInvalidMetadata is returned when the metadata document cannot be
+	// fetched or parsed or lacks required values.
+	ErrInvalidMetadata = errors.New("invalid metadata")
+)
+
+// Metadata contains the provider or authorization server metadata. Only the
+// members common to OpenID Connect Discovery and RFC 8414 are modeled.
+type Metadata struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint,omitempty"`
+	TokenEndpoint                     string   `json:"token_endpoint,omitempty"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint,omitempty"`
+	JWKSURI                           string   `json:"jwks_uri,omitempty"`
+	RegistrationEndpoint              string   `json:"registration_endpoint,omitempty"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint,omitempty"`
+	RevocationEndpoint                string   `json:"revocation_endpoint,omitempty"`
+	ScopesSupported                   []string `json:"scopes_supported,omitempty"`
+	ResponseTypesSupported            []string `json:"response_types_supported,omitempty"`
+	GrantTypesSupported               []string `json:"grant_types_supported,omitempty"`
+	SubjectTypesSupported             []string `json:"subject_types_supported,omitempty"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported,omitempty"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported,omitempty"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported,omitempty"`
+}
+
+// DiscoveryOptions defines the options for discovering a provider. The zero
+// value uses the defaults defined in this package.
+type DiscoveryOptions struct {
+	// Client is used to fetch the metadata. If nil, a client with a timeout
+	// of jwks.DefaultFetchTimeout is used.
+	Client *http.Client
+
+	// MaxResponseSize is the maximum size in bytes of the metadata document.
+	// If MaxResponseSize is less than or equal to zero
+	// DefaultMaxResponseSize is used.
+	MaxResponseSize int64
+
+	// RemoteOptions defines the options for fetching the provider's key
+	// set. If RemoteOptions.Client is nil, Client is used.
+	RemoteOptions jwks.RemoteOptions
+}
+
+// Provider is a discovered issuer. It provides the issuer's metadata and a
+// source for its signing keys.
+type Provider struct {
+	// Metadata is the issuer's metadata.
+	Metadata Metadata
+
+	// Keys fetches the keys from the issuer's "jwks_uri". Use Keys.Run to
+	// refresh them in the background.
+	Keys *jwks.Remote
+}
+
+// Discover fetches the OpenID Connect provider configuration of issuer from
+// the issuer's WellKnownOpenIDConfiguration URL and verifies that the
+// configuration's "issuer" equals issuer and that its "jwks_uri" is an https
+// URL.
+func Discover(ctx context.Context, issuer string, opts DiscoveryOptions) (*Provider, error) {
+	u, err := parseIssuer(issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/") + WellKnownOpenIDConfiguration
+
+	return discover(ctx, issuer, u.String(), opts)
+}
+
+// DiscoverOAuth fetches the authorization server metadata of issuer from
+// the URL constructed as specified in RFC 8414 section 3, which inserts
+// WellKnownOAuthAuthorizationServer between the issuer's host and path, and
+// verifies that the metadata's "issuer" equals issuer.
+func DiscoverOAuth(ctx context.Context, issuer string, opts DiscoveryOptions) (*Provider, error) {
+	u, err := parseIssuer(issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Path = WellKnownOAuthAuthorizationServer + strings.TrimSuffix(u.Path, "/")
+
+	return discover(ctx, issuer, u.String(), opts)
+}
+
+// parseIssuer parses issuer and checks that it is an https URL without query
+// and fragment as required by RFC 8414 section 2.
+func parseIssuer(issuer string) (*url.URL, error) {
+	u, err := url.Parse(issuer)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIssuer, err)
+	}
+
+	if u.Scheme != "https" || u.Host == "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return nil, fmt.Errorf("%w: %q must be an https URL without query and fragment", ErrInvalidIssuer, issuer)
+	}
+
+	return u, nil
+}
+
+func discover(ctx context.Context, issuer, metadataURL string, opts DiscoveryOptions) (*Provider, error) {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: jwks.DefaultFetchTimeout}
+	}
+	if opts.MaxResponseSize <= 0 {
+		opts.MaxResponseSize = DefaultMaxResponseSize
+	}
+	if opts.RemoteOptions.Client == nil {
+		opts.RemoteOptions.Client = opts.Client
+	}
+
+	md, err := fetchMetadata(ctx, metadataURL, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if md.Issuer != issuer {
+		return nil, fmt.Errorf("%w: metadata from %s contains %q instead of %q", ErrIssuerMismatch, metadataURL, md.Issuer, issuer)
+	}
+
+	if md.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: metadata from %s contains no jwks_uri", ErrInvalidMetadata, metadataURL)
+	}
+
+	if u, err := url.Parse(md.JWKSURI); err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("%w: metadata from %s contains invalid jwks_uri %q; it must be an https URL", ErrInvalidMetadata, metadataURL, md.JWKSURI)
+	}
+
+	return &Provider{
+		Metadata: md,
+		Keys:     jwks.NewRemote(md.JWKSURI, opts.RemoteOptions),
+	}, nil
+}
+
+func fetchMetadata(ctx context.Context, metadataURL string, opts DiscoveryOptions) (Metadata, error) {
+	var md Metadata
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL, nil)
+	if err != nil {
+		return md, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := opts.Client.Do(req)
+	if err != nil {
+		return md, fmt.Errorf("%w: failed to fetch %s: %v", ErrInvalidMetadata, metadataURL, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return md, fmt.Errorf("%w: failed to fetch %s: unexpected status %d", ErrInvalidMetadata, metadataURL, res.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, opts.MaxResponseSize+1))
+	if err != nil {
+		return md, fmt.Errorf("%w: failed to fetch %s: %v", ErrInvalidMetadata, metadataURL, err)
+	}
+	if int64(len(body)) > opts.MaxResponseSize {
+		return md, fmt.Errorf("%w: failed to fetch %s: response exceeds %d bytes", ErrInvalidMetadata, metadataURL, opts.MaxResponseSize)
+	}
+
+	if err := json.Unmarshal(body, &md); err != nil {
+		return md, fmt.Errorf("%w: failed to parse %s: %v", ErrInvalidMetadata, metadataURL, err)
+	}
+
+	return md, nil
+}
+
+// KeyResolver returns a jwt.KeyResolver resolving the issuer's keys.
+func (p *Provider) KeyResolver() jwt.KeyResolver {
+	return p.Keys
+}
+
+// Issuer returns a jwt.Verifier verifying the "iss" claim equals the
+// issuer.
+func (p *Provider) Issuer() jwt.Verifier {
+	return jwt.Issuer(p.Metadata.Issuer)
+}
+
+// Verifier returns a jwt.Verifier verifying the token's signature using the
+// issuer's keys, the "iss" claim and then applying verifiers.
+func (p *Provider) Verifier(verifiers ...jwt.Verifier) jwt.Verifier {
+	return jwt.AllOf(append([]jwt.Verifier{
+		jwt.SignatureFromResolver(p.KeyResolver()),
+		p.Issuer(),
+	}, verifiers...)...)
+}
@@ -0,0 +1,199 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/halimath/jose/jwk"
+	"github.com/halimath/jose/jwks"
+	"github.com/halimath/jose/jws"
+	"github.com/halimath/jose/jwt"
+)
+
+// fakeIssuer serves metadata and a key set for the issuers "<URL>" and
+// "<URL>/tenant" using both well-known URL forms. If claimedIssuer is set,
+// it is returned as the metadata's issuer. If jwksURI is set, it is returned
+// as the metadata's jwks_uri.
+type fakeIssuer struct {
+	*httptest.Server
+	signer        jws.Signer
+	claimedIssuer string
+	jwksURI       string
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := jws.ES256Signer(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := jwks.NewHandler(jwk.Set{&jwk.ECDSAPublicKey{
+		KeyDescription: jwk.KeyDescription{KeyID: "1", KeyUse: jwk.UseSignature},
+		PublicKey:      &pk.PublicKey,
+	}}, jwks.HandlerOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fakeIssuer{signer: signer}
+
+	metadata := func(issuer string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			md := Metadata{
+				Issuer:        f.URL + issuer,
+				TokenEndpoint: f.URL + issuer + "/token",
+				JWKSURI:       f.URL + "/keys",
+			}
+			if f.claimedIssuer != "" {
+				md.Issuer = f.claimedIssuer
+			}
+			if f.jwksURI != "" {
+				md.JWKSURI = f.jwksURI
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(md)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/keys", keys)
+	mux.Handle(WellKnownOpenIDConfiguration, metadata(""))
+	mux.Handle("/tenant"+WellKnownOpenIDConfiguration, metadata("/tenant"))
+	mux.Handle(WellKnownOAuthAuthorizationServer, metadata(""))
+	mux.Handle(WellKnownOAuthAuthorizationServer+"/tenant", metadata("/tenant"))
+
+	f.Server = httptest.NewTLSServer(mux)
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+func (f *fakeIssuer) sign(t *testing.T, issuer string) *jwt.Token {
+	t.Helper()
+
+	b := jwt.Builder{Signer: f.signer, KeyID: "1", Issuer: issuer, TTL: time.Minute}
+	token, err := b.Sign(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func TestDiscover(t *testing.T) {
+	f := newFakeIssuer(t)
+	opts := DiscoveryOptions{Client: f.Client()}
+
+	discoverers := map[string]func(context.Context, string, DiscoveryOptions) (*Provider, error){
+		"openid": Discover,
+		"oauth":  DiscoverOAuth,
+	}
+
+	for name, discover := range discoverers {
+		for _, path := range []string{"", "/tenant"} {
+			t.Run(name+path, func(t *testing.T) {
+				issuer := f.URL + path
+
+				p, err := discover(context.Background(), issuer, opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if p.Metadata.TokenEndpoint != issuer+"/token" {
+					t.Errorf("unexpected token endpoint: %q", p.Metadata.TokenEndpoint)
+				}
+
+				if err := f.sign(t, issuer).Verify(p.Verifier(jwt.ExpirationTime(0))); err != nil {
+					t.Error(err)
+				}
+
+				if err := f.sign(t, "https://other.example.com").Verify(p.Verifier()); !errors.Is(err, jwt.ErrIssuerMismatch) {
+					t.Errorf("expected jwt.ErrIssuerMismatch but got %v", err)
+				}
+			})
+		}
+	}
+}
+
+func TestDiscover_issuerMismatch(t *testing.T) {
+	f := newFakeIssuer(t)
+	f.claimedIssuer = "https://evil.example.com"
+
+	_, err := Discover(context.Background(), f.URL, DiscoveryOptions{Client: f.Client()})
+	if !errors.Is(err, ErrIssuerMismatch) {
+		t.Errorf("expected ErrIssuerMismatch but got %v", err)
+	}
+}
+
+func TestDiscover_invalidJWKSURI(t *testing.T) {
+	f := newFakeIssuer(t)
+
+	uris := []string{
+		"http" + strings.TrimPrefix(f.URL, "https") + "/keys",
+		"/keys",
+		"https://",
+	}
+
+	for _, uri := range uris {
+		t.Run(uri, func(t *testing.T) {
+			f.jwksURI = uri
+
+			_, err := Discover(context.Background(), f.URL, DiscoveryOptions{Client: f.Client()})
+			if !errors.Is(err, ErrInvalidMetadata) {
+				t.Errorf("expected ErrInvalidMetadata but got %v", err)
+			}
+		})
+	}
+}
+
+func TestDiscover_trailingSlash(t *testing.T) {
+	f := newFakeIssuer(t)
+
+	_, err := Discover(context.Background(), f.URL+"/", DiscoveryOptions{Client: f.Client()})
+	if !errors.Is(err, ErrIssuerMismatch) {
+		t.Errorf("expected ErrIssuerMismatch but got %v", err)
+	}
+}
+
+func TestDiscover_notFound(t *testing.T) {
+	f := newFakeIssuer(t)
+
+	_, err := Discover(context.Background(), f.URL+"/unknown", DiscoveryOptions{Client: f.Client()})
+	if !errors.Is(err, ErrInvalidMetadata) {
+		t.Errorf("expected ErrInvalidMetadata but got %v", err)
+	}
+}
+
+func TestDiscover_invalidIssuer(t *testing.T) {
+	issuers := []string{
+		"http://example.com",
+		"https://example.com?tenant=1",
+		"https://example.com#tenant",
+		"example.com",
+		"://",
+	}
+
+	for _, issuer := range issuers {
+		t.Run(issuer, func(t *testing.T) {
+			if _, err := Discover(context.Background(), issuer, DiscoveryOptions{}); !errors.Is(err, ErrInvalidIssuer) {
+				t.Errorf("expected ErrInvalidIssuer but got %v", err)
+			}
+		})
+	}
+}
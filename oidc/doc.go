@@ -0,0 +1,5 @@
+// Package oidc implements discovery of an issuer's keys and metadata using
+// OpenID Connect Discovery 1.0 (https://openid.net/specs/openid-connect-discovery-1_0.html)
+// and OAuth 2.0 Authorization Server Metadata as specified in RFC 8414
+// (https://datatracker.ietf.org/doc/html/rfc8414).
+package oidc